@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/maruel/panicparse/stack"
+)
+
+// AuthorSummary aggregates the stack frames blamed on a single author.
+type AuthorSummary struct {
+	Author Identity
+	Team   string
+	// Frames are the distinct stack lines blamed on the author.
+	Frames []stack.Call
+	// Commits are the author's blamed commits, newest first.
+	Commits  []*Commit
+	Newest   time.Time
+	Packages []string
+}
+
+// TeamSummary groups the authors of a team, as given by the people
+// directory.
+type TeamSummary struct {
+	Team    string
+	Authors []AuthorSummary
+	Frames  int
+	Commits int
+	Newest  time.Time
+}
+
+// Authors aggregates the blamed frames by author, the authors of most frames
+// first. The frames blamed on the bump of their dependency or of the standard
+// library are left out, their commits did not write them.
+func (d *Dump) Authors() []AuthorSummary {
+	byEmail := map[string]*AuthorSummary{}
+	var order []string
+	seen := map[string]bool{}
+	for _, b := range d.Buckets {
+		for _, c := range b.Stack.Calls {
+			source := c.FullSourceLine()
+			cm := d.Commits.BySource[source]
+			if cm == nil || seen[source] || d.Dependencies[source].bumped() {
+				continue
+			}
+			seen[source] = true
+
+			key := strings.ToLower(cm.Email)
+			a, ok := byEmail[key]
+			if !ok {
+				a = &AuthorSummary{Author: cm.Identity()}
+				if p := d.Person(cm); p != nil {
+					a.Team = p.Team
+				}
+				byEmail[key] = a
+				order = append(order, key)
+			}
+			a.Frames = append(a.Frames, c)
+			a.addCommit(cm)
+			a.addPackage(c.Func.PkgName())
+		}
+	}
+
+	authors := make([]AuthorSummary, len(order))
+	for i, key := range order {
+		authors[i] = *byEmail[key]
+	}
+	sort.SliceStable(authors, func(i, j int) bool {
+		return len(authors[i].Frames) > len(authors[j].Frames)
+	})
+	return authors
+}
+
+func (a *AuthorSummary) addCommit(cm *Commit) {
+	for _, c := range a.Commits {
+		if c == cm {
+			return
+		}
+	}
+	a.Commits = append(a.Commits, cm)
+	sort.Stable(byDate(a.Commits))
+	a.Newest = a.Commits[0].Date
+}
+
+func (a *AuthorSummary) addPackage(pkg string) {
+	for _, p := range a.Packages {
+		if p == pkg {
+			return
+		}
+	}
+	a.Packages = append(a.Packages, pkg)
+}
+
+// CommitIDs returns the IDs of the author's commits.
+func (a *AuthorSummary) CommitIDs() []string {
+	ids := make([]string, len(a.Commits))
+	for i, c := range a.Commits {
+		ids[i] = c.ID
+	}
+	return ids
+}
+
+// Teams groups the authors by team, the teams with most frames first. It
+// returns nil if no author belongs to a team.
+func Teams(authors []AuthorSummary) []TeamSummary {
+	byTeam := map[string]*TeamSummary{}
+	var order []string
+	for _, a := range authors {
+		t, ok := byTeam[a.Team]
+		if !ok {
+			t = &TeamSummary{Team: a.Team}
+			byTeam[a.Team] = t
+			order = append(order, a.Team)
+		}
+		t.Authors = append(t.Authors, a)
+		t.Frames += len(a.Frames)
+		t.Commits += len(a.Commits)
+		if a.Newest.After(t.Newest) {
+			t.Newest = a.Newest
+		}
+	}
+	if _, ok := byTeam[""]; ok && len(byTeam) == 1 {
+		return nil
+	}
+
+	teams := make([]TeamSummary, len(order))
+	for i, team := range order {
+		teams[i] = *byTeam[team]
+	}
+	sort.SliceStable(teams, func(i, j int) bool {
+		return teams[i].Frames > teams[j].Frames
+	})
+	return teams
+}
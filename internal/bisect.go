@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/maruel/panicparse/stack"
+)
+
+// BisectStep is a revision tested while bisecting.
+type BisectStep struct {
+	Revision string
+	// Bad tells whether the command crashed the same way as the dump, and
+	// Skipped whether the revision could not be tested: the command
+	// exited with 125, timed out, failed without a goroutine dump or
+	// crashed another way.
+	Bad     bool
+	Skipped bool `json:",omitempty"`
+}
+
+// Bisection is the outcome of bisecting the crash with a reproduction
+// command.
+type Bisection struct {
+	Good    string
+	Bad     string
+	Command string
+	Steps   []BisectStep
+	// Culprit is the first bad commit, nil if the bisection did not end.
+	Culprit *Commit
+	// Error tells why the bisection did not end, if it did not.
+	Error string `json:",omitempty"`
+}
+
+// defaultBisectTimeout bounds each run of the bisection command.
+const defaultBisectTimeout = 10 * time.Minute
+
+// skipExitCode is the exit code of the commands that cannot test a
+// revision, as with git bisect run.
+const skipExitCode = 125
+
+var (
+	reFirstBad = regexp.MustCompile(`(?m)^([0-9a-f]{40}) is the first bad commit`)
+	reCommitID = regexp.MustCompile(`(?m)^[0-9a-f]{40}$`)
+)
+
+// fingerprint identifies a stack by the functions of the code of the
+// repository it went through, innermost first.
+func fingerprint(calls []stack.Call) string {
+	var funcs []string
+	for i := range calls {
+		if c := &calls[i]; isOwnCode(c) {
+			funcs = append(funcs, c.Func.PkgDotName())
+		}
+	}
+	return strings.Join(funcs, "\n")
+}
+
+// panicking returns the fingerprint of the panicking goroutine in the output
+// of a command, or "" if it holds no goroutine dump.
+func panicking(output []byte) string {
+	routines, err := stack.ParseDump(bytes.NewReader(output), ioutil.Discard)
+	if err != nil {
+		return ""
+	}
+	for _, r := range routines {
+		if r.First {
+			return fingerprint(r.Stack.Calls)
+		}
+	}
+	return ""
+}
+
+// Bisect runs git bisect between the good and bad revisions in a temporary
+// worktree, running the shell command at each step for at most the timeout.
+// A revision is bad only if the output of the command holds a goroutine dump
+// whose panicking stack matches the one of the dump, and good only if the
+// command succeeded; the other ones are skipped.
+// Errors are recorded in the returned bisection.
+func (d *Dump) Bisect(good, bad, command string,
+	timeout time.Duration) *Bisection {
+	b := &Bisection{Good: good, Bad: bad, Command: command}
+	if err := d.bisect(b, timeout); err != nil {
+		b.Error = err.Error()
+	}
+	return b
+}
+
+func (d *Dump) bisect(b *Bisection, timeout time.Duration) error {
+	var want string
+	for _, bucket := range d.Buckets {
+		if bucket.First() {
+			want = fingerprint(bucket.Stack.Calls)
+		}
+	}
+	if want == "" {
+		return fmt.Errorf("No panicking goroutine to bisect")
+	}
+	if timeout <= 0 {
+		timeout = defaultBisectTimeout
+	}
+
+	dir, err := ioutil.TempDir("", "iblameyou-bisect")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	repo := d.source.Repository
+	if _, err := git(repo, "worktree", "add", "--detach", dir, b.Bad); err != nil {
+		return fmt.Errorf("Failed to create a worktree at %s", b.Bad)
+	}
+	defer git(repo, "worktree", "remove", "--force", dir)
+
+	out, err := git(dir, "bisect", "start", b.Bad, b.Good)
+	if err != nil {
+		return fmt.Errorf("Failed to bisect %s..%s", b.Good, b.Bad)
+	}
+	defer git(dir, "bisect", "reset")
+	for {
+		if m := reFirstBad.FindSubmatch(out); m != nil {
+			// Not indexed in the dump, which may be shown meanwhile.
+			cm, err := LoadCommit(repo, string(m[1]))
+			if err != nil {
+				return err
+			}
+			d.resolveIdentities(&cm)
+			b.Culprit = &cm
+			return nil
+		}
+		if bytes.Contains(out, []byte("only 'skip'ped commits left")) {
+			var ids []string
+			for _, id := range reCommitID.FindAll(out, -1) {
+				ids = append(ids, fmt.Sprintf("%.7s", id))
+			}
+			return fmt.Errorf("Only skipped commits left to test, the first "+
+				"bad commit is one of %s", strings.Join(ids, ", "))
+		}
+		head, err := git(dir, "rev-parse", "HEAD")
+		if err != nil {
+			return err
+		}
+		step := BisectStep{Revision: strings.TrimSpace(string(head))}
+		step.Bad, step.Skipped = runStep(dir, b.Command, want, timeout)
+		b.Steps = append(b.Steps, step)
+
+		verdict := "good"
+		switch {
+		case step.Skipped:
+			verdict = "skip"
+		case step.Bad:
+			verdict = "bad"
+		}
+		if out, err = git(dir, "bisect", verdict); err != nil &&
+			!bytes.Contains(out, []byte("only 'skip'ped commits left")) {
+			return fmt.Errorf("Bisection failed at %.7s", step.Revision)
+		}
+	}
+}
+
+// runStep runs the command in the worktree and tells whether the revision is
+// bad or must be skipped.
+func runStep(dir, command, want string, timeout time.Duration) (bad, skip bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	// Do not wait for the children of the shell holding the output open.
+	cmd.WaitDelay = time.Second
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return false, true
+	}
+	if got := panicking(output); got != "" {
+		return got == want, got != want
+	}
+	if exit, ok := err.(*exec.ExitError); ok && exit.ExitCode() == skipExitCode {
+		return false, true
+	}
+	return false, err != nil
+}
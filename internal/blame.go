@@ -19,9 +19,41 @@ type Commit struct {
 	Message     string
 	FullMessage string
 
+	// Trailers are the "Key: value" lines closing the full message, and
+	// CoAuthors, Reviewers and SignedOffBy the people named in the most
+	// common of them.
+	Trailers    []Trailer
+	CoAuthors   []Identity
+	Reviewers   []Identity
+	SignedOffBy []Identity
+
 	// Date is the date when this commit was originally made. (It may
 	// differ from the commit date, which is changed during rebases, etc.)
 	Date time.Time
+
+	// Repository names the repository the commit is in, when it is not the
+	// one of the Source.
+	Repository string `json:",omitempty"`
+}
+
+// notCommitted is the ID git blame gives to the lines changed in the working
+// tree.
+const notCommitted = "0000000000000000000000000000000000000000"
+
+// Uncommitted tells whether the commit stands for the changes not committed
+// yet in the working tree.
+func (c *Commit) Uncommitted() bool {
+	return c.ID == notCommitted
+}
+
+// Identity returns the author of the commit.
+func (c *Commit) Identity() Identity {
+	return Identity{Name: c.Author, Email: c.Email}
+}
+
+// SetIdentity replaces the author of the commit.
+func (c *Commit) SetIdentity(id Identity) {
+	c.Author, c.Email = id.Name, id.Email
 }
 
 type Commits struct {
@@ -48,6 +80,27 @@ func (cms *Commits) Add(source string, cm Commit) {
 	cms.BySource[source] = ptr
 }
 
+// Index registers a commit found outside of blame, e.g. in the history of a
+// line, and returns the registered one. Such commits are only indexed by ID.
+func (cms *Commits) Index(cm Commit) *Commit {
+	if found, ok := cms.ByID[cm.ID]; ok {
+		return found
+	}
+	cms.ByID[cm.ID] = &cm
+	return &cm
+}
+
+// Get returns the commits with the given IDs.
+func (cms *Commits) Get(ids []string) []*Commit {
+	commits := make([]*Commit, 0, len(ids))
+	for _, id := range ids {
+		if c, ok := cms.ByID[id]; ok {
+			commits = append(commits, c)
+		}
+	}
+	return commits
+}
+
 func (cms *Commits) SortByDate() {
 	sort.Stable(byDate(cms.All))
 }
@@ -59,12 +112,17 @@ func (c byDate) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
 func (c byDate) Less(i, j int) bool { return c[i].Date.After(c[j].Date) }
 
 // Blame returns the commit information about the person that committed the
-// single line in given file in given repository.
+// single line in given file in given repository. Without a revision the
+// working tree is blamed, and the lines not committed yet are attributed to
+// the local user.
 // Heavily influenced by https://github.com/sourcegraph/go-blame/
 func Blame(repo, file string, line int, revision string) (Commit, error) {
-	lineOpt := fmt.Sprintf("-L%[1]d,%[1]d", line)
-	cmd := exec.Command("git", "blame", "-w", "--porcelain", lineOpt, revision,
-		"--", file)
+	args := []string{"blame", "-w", "--porcelain",
+		fmt.Sprintf("-L%[1]d,%[1]d", line)}
+	if revision != "" {
+		args = append(args, revision)
+	}
+	cmd := exec.Command("git", append(args, "--", file)...)
 	cmd.Dir = repo
 	cmd.Stderr = ioutil.Discard
 	out, err := cmd.Output()
@@ -111,6 +169,13 @@ func Blame(repo, file string, line int, revision string) (Commit, error) {
 		Date:    time.Unix(date, 0),
 	}
 
+	if commit.Uncommitted() {
+		commit.SetIdentity(localIdentity(repo))
+		commit.Message = "Not committed yet"
+		commit.FullMessage = commit.Message
+		return commit, nil
+	}
+
 	// Get the full message
 	cmd = exec.Command("git", "show", "-s", "--format=%B", commit.ID)
 	cmd.Dir = repo
@@ -118,7 +183,48 @@ func Blame(repo, file string, line int, revision string) (Commit, error) {
 	out, err = cmd.Output()
 	if err == nil {
 		commit.FullMessage = strings.TrimSpace(string(out))
+		commit.setTrailers()
 	}
 
 	return commit, nil
 }
+
+// localIdentity returns the identity of the local user, as configured for
+// the repository.
+func localIdentity(repo string) Identity {
+	var id Identity
+	if out, err := git(repo, "config", "user.name"); err == nil {
+		id.Name = strings.TrimSpace(string(out))
+	}
+	if out, err := git(repo, "config", "user.email"); err == nil {
+		id.Email = strings.TrimSpace(string(out))
+	}
+	return id
+}
+
+// LoadCommit returns the information about the commit with the given ID.
+func LoadCommit(repo, id string) (Commit, error) {
+	out, err := git(repo, "show", "-s", "--format=%H%x00%aN%x00%aE%x00%at%x00%B",
+		id)
+	if err != nil {
+		return Commit{}, err
+	}
+	fields := strings.SplitN(string(out), "\x00", 5)
+	if len(fields) != 5 {
+		return Commit{}, fmt.Errorf("Unexpected git output for commit %s", id)
+	}
+	date, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return Commit{}, fmt.Errorf("Failed to parse author-time %q", fields[3])
+	}
+	commit := Commit{
+		ID:          fields[0],
+		Author:      fields[1],
+		Email:       fields[2],
+		Date:        time.Unix(date, 0),
+		FullMessage: strings.TrimSpace(fields[4]),
+	}
+	commit.Message = strings.SplitN(commit.FullMessage, "\n", 2)[0]
+	commit.setTrailers()
+	return commit, nil
+}
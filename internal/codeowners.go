@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// codeOwnersLocations lists the places where GitHub and GitLab look for the
+// CODEOWNERS file, in order of precedence.
+var codeOwnersLocations = []string{
+	".github/CODEOWNERS",
+	".gitlab/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+}
+
+type ownerRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+type ownerSection struct {
+	name     string
+	defaults []string
+	rules    []ownerRule
+}
+
+// CodeOwners is a parsed CODEOWNERS file. Rules are grouped in sections, as
+// in GitLab; a GitHub file is a single, unnamed section.
+type CodeOwners struct {
+	sections []*ownerSection
+}
+
+// loadCodeOwners reads the CODEOWNERS file of the repository at the given
+// revision. It returns nil if the repository has none.
+func loadCodeOwners(repo, revision string) *CodeOwners {
+	for _, location := range codeOwnersLocations {
+		out, err := git(repo, "show", revision+":"+location)
+		if err == nil {
+			return parseCodeOwners(string(out))
+		}
+	}
+	return nil
+}
+
+var reSection = regexp.MustCompile(`^\^?\[([^\]]+)\](?:\[\d+\])?\s*(.*)$`)
+
+func parseCodeOwners(content string) *CodeOwners {
+	section := &ownerSection{}
+	co := &CodeOwners{sections: []*ownerSection{section}}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if m := reSection.FindStringSubmatch(line); m != nil {
+			section = &ownerSection{name: m[1], defaults: fieldsNoComment(m[2])}
+			co.sections = append(co.sections, section)
+			continue
+		}
+
+		fields := fieldsNoComment(line)
+		pattern, err := ownerPattern(fields[0])
+		if err != nil {
+			continue
+		}
+		owners := fields[1:]
+		if len(owners) == 0 {
+			owners = section.defaults
+		}
+		section.rules = append(section.rules, ownerRule{pattern, owners})
+	}
+	return co
+}
+
+// fieldsNoComment splits the line into fields, dropping a trailing comment.
+func fieldsNoComment(line string) []string {
+	fields := strings.Fields(strings.Replace(line, `\ `, "\x00", -1))
+	for i := range fields {
+		if strings.HasPrefix(fields[i], "#") {
+			fields = fields[:i]
+			break
+		}
+		fields[i] = strings.Replace(fields[i], "\x00", " ", -1)
+		fields[i] = strings.Replace(fields[i], `\#`, "#", -1)
+	}
+	return fields
+}
+
+// ownerPattern translates a gitignore-style pattern into a regular expression
+// matching the repository-relative paths of the files it covers.
+func ownerPattern(p string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(strings.TrimSuffix(p, "/"), "/")
+	p = strings.TrimPrefix(p, "/")
+	dir := strings.HasSuffix(p, "/")
+	p = strings.TrimSuffix(p, "/")
+
+	re := new(strings.Builder)
+	re.WriteString("^")
+	if !anchored {
+		re.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; {
+		case strings.HasPrefix(p[i:], "**/"):
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	// A single star does not cross a slash: dir/* only covers the files
+	// directly in dir, not the whole directories it matches.
+	last := p[strings.LastIndex(p, "/")+1:]
+	switch {
+	case dir:
+		re.WriteString("/.*$")
+	case strings.Contains(last, "*") && last != "**":
+		re.WriteString("$")
+	default:
+		re.WriteString("(?:/.*)?$")
+	}
+	return regexp.Compile(re.String())
+}
+
+// Owners returns the owners of the file with the given repository-relative
+// path. Within a section the last matching rule wins; the owners from all the
+// sections are combined.
+func (co *CodeOwners) Owners(path string) []string {
+	if co == nil {
+		return nil
+	}
+	path = filepath.ToSlash(path)
+	var owners []string
+	seen := map[string]bool{}
+	for _, section := range co.sections {
+		for i := len(section.rules) - 1; i >= 0; i-- {
+			rule := &section.rules[i]
+			if !rule.pattern.MatchString(path) {
+				continue
+			}
+			for _, owner := range rule.owners {
+				if !seen[owner] {
+					seen[owner] = true
+					owners = append(owners, owner)
+				}
+			}
+			break
+		}
+	}
+	return owners
+}
+
+// relativePath returns the path of the file relative to the repository, or
+// an empty string if the file is outside of it.
+func relativePath(repo, file string) string {
+	if !filepath.IsAbs(file) {
+		return file
+	}
+	rel, err := filepath.Rel(repo, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return rel
+}
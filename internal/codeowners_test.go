@@ -0,0 +1,42 @@
+package internal
+
+import "testing"
+
+func TestOwnerPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"*", "a.go", true},
+		{"*", "dir/a.go", true},
+		{"*.go", "dir/a.go", true},
+		{"*.go", "dir/a.txt", false},
+		{"dir/*", "dir/a.go", true},
+		{"dir/*", "dir/a/b.go", false},
+		{"dir/*.go", "dir/a/b.go", false},
+		{"dir/", "dir/a/b.go", true},
+		{"dir/", "other/dir/a.go", true},
+		{"/dir/", "other/dir/a.go", false},
+		{"dir", "dir/a/b.go", true},
+		{"/dir/a", "dir/a/b.go", true},
+		{"/dir/a", "dir/ab.go", false},
+		{"dir/**", "dir/a/b.go", true},
+		{"**/lib", "x/y/lib/a.go", true},
+		{"dir/**/a.go", "dir/x/y/a.go", true},
+		{"dir/**/a.go", "dir/a.go", true},
+		{"a?.go", "ab.go", true},
+		{"a?.go", "a/.go", false},
+	}
+	for _, test := range tests {
+		re, err := ownerPattern(test.pattern)
+		if err != nil {
+			t.Errorf("ownerPattern(%q): %v", test.pattern, err)
+			continue
+		}
+		if got := re.MatchString(test.path); got != test.match {
+			t.Errorf("ownerPattern(%q) matching %q = %v, want %v",
+				test.pattern, test.path, got, test.match)
+		}
+	}
+}
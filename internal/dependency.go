@@ -0,0 +1,242 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/maruel/panicparse/stack"
+)
+
+// manifests are the files recording the versions of the dependencies, most
+// specific first.
+var manifests = []string{"go.mod", "glide.lock", "vendor/modules.txt", "go.sum"}
+
+// Dependency is the module a stack frame is in, when it is not the code of
+// the repository.
+type Dependency struct {
+	Module string
+	// Version is the one in the module cache path, if any.
+	Version string
+	// Old and New are the versions before and after the commit that last
+	// changed the module, as given by Manifest. Old is empty when the
+	// commit added the module.
+	Old, New string
+	Manifest string
+
+	// File is the path of the source file in the module.
+	File string
+	// Upstream is the clone of the repository of the module the frame was
+	// blamed in, if any, at Revision, where the file is at Path.
+	Upstream string `json:",omitempty"`
+	Revision string `json:",omitempty"`
+	Path     string `json:",omitempty"`
+}
+
+// bumped tells whether the frame was blamed on the commit that changed the
+// version of its module, rather than on its line in an upstream clone.
+func (dep *Dependency) bumped() bool {
+	return dep != nil && dep.Upstream == ""
+}
+
+var (
+	reModCache = regexp.MustCompile(`/pkg/mod/(.+?)@([^/]+)/`)
+	reUpper    = regexp.MustCompile(`!([a-z])`)
+)
+
+// dependencies finds the commits that changed the versions of the modules
+// of the dependency frames.
+type dependencies struct {
+	repo, revision string
+	// modules are the module paths listed in the manifests, used to tell
+	// which module a vendored package belongs to.
+	modules []string
+
+	// glide tells whether there is a glide.lock, whose versions are not on
+	// the lines naming the modules.
+	glide bool
+
+	mu    sync.Mutex
+	bumps map[string]*bump
+}
+
+type bump struct {
+	once   sync.Once
+	commit Commit
+	dep    Dependency
+	err    error
+}
+
+func newDependencies(repo, revision string) *dependencies {
+	deps := &dependencies{repo: repo, revision: revision,
+		bumps: map[string]*bump{}}
+	for _, manifest := range manifests[:3] {
+		out, err := git(repo, "show", revision+":"+manifest)
+		if err != nil {
+			continue
+		}
+		if manifest == "glide.lock" {
+			deps.glide = true
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			fields := strings.Fields(strings.TrimPrefix(line, "require "))
+			switch {
+			case len(fields) >= 3 && fields[0] == "-" && fields[1] == "name:":
+				deps.modules = append(deps.modules, fields[2])
+			case len(fields) >= 2 && fields[0] == "#":
+				deps.modules = append(deps.modules, fields[1])
+			case len(fields) >= 2 && strings.HasPrefix(fields[1], "v") &&
+				strings.Contains(fields[0], "."):
+				deps.modules = append(deps.modules, fields[0])
+			}
+		}
+	}
+	return deps
+}
+
+// dependency returns the module of the call, or nil if it is not in a
+// vendored or cached dependency.
+func (deps *dependencies) dependency(c *stack.Call) *Dependency {
+	if c.IsStdlib() {
+		// The standard library vendors packages too.
+		return nil
+	}
+	if m := reModCache.FindStringSubmatch(c.SourcePath); m != nil {
+		// Upper case letters are escaped in the module cache.
+		module := reUpper.ReplaceAllStringFunc(m[1], func(s string) string {
+			return strings.ToUpper(s[1:])
+		})
+		file := c.SourcePath[strings.Index(c.SourcePath, m[0])+len(m[0]):]
+		return &Dependency{Module: module, Version: m[2], File: file}
+	}
+	i := strings.LastIndex(c.SourcePath, "/vendor/")
+	if i < 0 {
+		return nil
+	}
+	pkg := c.SourcePath[i+len("/vendor/"):]
+	if j := strings.LastIndex(pkg, "/"); j >= 0 {
+		pkg = pkg[:j]
+	}
+	module := ""
+	for _, m := range deps.modules {
+		if (pkg == m || strings.HasPrefix(pkg, m+"/")) && len(m) > len(module) {
+			module = m
+		}
+	}
+	if module == "" {
+		// Assume the usual host/owner/repository layout.
+		parts := strings.SplitN(pkg, "/", 4)
+		if len(parts) > 3 {
+			parts = parts[:3]
+		}
+		module = strings.Join(parts, "/")
+	}
+	file := strings.TrimPrefix(c.SourcePath[i+len("/vendor/"):], module+"/")
+	return &Dependency{Module: module, File: file}
+}
+
+// Blame returns the last commit that changed the version of the module, in
+// the manifests or else in the vendor tree, with the versions it changed.
+func (deps *dependencies) Blame(dep *Dependency) (Commit, Dependency, error) {
+	deps.mu.Lock()
+	b, ok := deps.bumps[dep.Module]
+	if !ok {
+		b = &bump{dep: *dep}
+		deps.bumps[dep.Module] = b
+	}
+	deps.mu.Unlock()
+	b.once.Do(func() { deps.blame(b) })
+	return b.commit, b.dep, b.err
+}
+
+func (deps *dependencies) blame(b *bump) {
+	module := b.dep.Module
+	args := []string{"log", "--format=%H", deps.revision}
+	if !deps.glide {
+		// The other manifests name the module on the lines of its version.
+		args = append(args, "-G"+regexp.QuoteMeta(module))
+	}
+	out, err := git(deps.repo, append(append(args, "--"), manifests...)...)
+	if err == nil {
+		for _, id := range strings.Fields(string(out)) {
+			if deps.versions(id, &b.dep) {
+				b.commit, b.err = LoadCommit(deps.repo, id)
+				return
+			}
+		}
+	}
+
+	out, err = git(deps.repo, "log", "-1", "--format=%H", deps.revision,
+		"--", "vendor/"+module)
+	id := strings.TrimSpace(string(out))
+	if err != nil || id == "" {
+		b.err = fmt.Errorf("No commit changed %s", module)
+		return
+	}
+	b.commit, b.err = LoadCommit(deps.repo, id)
+}
+
+// versions finds the old and new versions of the module in the diff of the
+// first manifest the commit changed it in. It returns false if the commit
+// did not change the version.
+func (deps *dependencies) versions(id string, dep *Dependency) bool {
+	out, err := git(deps.repo, append([]string{"show", "--format=",
+		"--no-color", "--no-ext-diff", id, "--"}, manifests...)...)
+	if err != nil {
+		return false
+	}
+	type change struct{ older, newer string }
+	changes := map[string]*change{}
+	var current *change
+	name := ""
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "diff --git a/") {
+			file := strings.SplitN(line[len("diff --git a/"):], " ", 2)[0]
+			current = &change{}
+			changes[file] = current
+			name = ""
+			continue
+		}
+		if current == nil || line == "" || strings.HasPrefix(line, "---") ||
+			strings.HasPrefix(line, "+++") {
+			continue
+		}
+		sign, text := line[:1], line[1:]
+		fields := strings.Fields(strings.TrimPrefix(
+			strings.TrimSpace(text), "require "))
+		version := ""
+		switch {
+		case len(fields) >= 3 && fields[0] == "-" && fields[1] == "name:":
+			// glide.lock: the version follows the name.
+			name = fields[2]
+		case len(fields) >= 2 && fields[0] == "version:":
+			if name == dep.Module {
+				version = fields[1]
+			}
+		case len(fields) >= 3 && fields[0] == "#":
+			// vendor/modules.txt
+			if fields[1] == dep.Module {
+				version = fields[2]
+			}
+		case len(fields) >= 2 && fields[0] == dep.Module:
+			version = strings.TrimSuffix(fields[1], "/go.mod")
+		}
+		switch {
+		case version == "":
+		case sign == "-" && current.older == "":
+			current.older = version
+		case sign == "+" && current.newer == "":
+			current.newer = version
+		}
+	}
+
+	for _, manifest := range manifests {
+		c := changes[manifest]
+		if c != nil && c.older != c.newer {
+			dep.Old, dep.New, dep.Manifest = c.older, c.newer, manifest
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"strings"
+)
+
+// Diff returns the lines of the patch introduced by the commit. Unless whole
+// is set, the patch is limited to the given source file.
+// The changes not committed yet are diffed against HEAD.
+func (d *Dump) Diff(commit, file string, whole bool) ([]string, error) {
+	args := []string{"show", "--format=", "--no-color", "--no-ext-diff", commit}
+	if commit == notCommitted {
+		args = []string{"diff", "--no-color", "--no-ext-diff", "HEAD"}
+	}
+	repo := &Repository{Path: d.source.Repository}
+	if cm, ok := d.Commits.ByID[commit]; ok && cm.Repository != "" &&
+		d.repositories != nil {
+		if r := d.repositories.find(cm.Repository); r != nil {
+			repo = r
+		}
+	}
+	if path := repo.relative(file); !whole && path != "" {
+		args = append(args, "--", path)
+	}
+	out, err := git(repo.Path, args...)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimRight(string(out), "\n"), "\n"), nil
+}
@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// targetBranches are tried in order when no target is configured.
+var targetBranches = []string{"origin/main", "origin/master", "HEAD"}
+
+// Fix lists the commits after the crash revision, up to the target branch,
+// that may have fixed a stack frame, oldest first.
+type Fix struct {
+	// Line are the commits that changed the blamed line itself.
+	Line []*Commit
+	// Function are the ones that changed only other lines of its function.
+	Function []*Commit
+}
+
+// Commits returns all the commits of the fix, the ones changing the line
+// first.
+func (f *Fix) Commits() []*Commit {
+	return append(append([]*Commit{}, f.Line...), f.Function...)
+}
+
+// resolveTarget returns the revision to look for fixes in: the configured
+// one, or the first of the usual branches that exists.
+func resolveTarget(repo, target string) (string, error) {
+	if target != "" {
+		out, err := git(repo, "rev-parse", "--verify", "--quiet",
+			target+"^{commit}")
+		if err != nil {
+			return "", fmt.Errorf("Unknown target revision %s", target)
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+	for _, branch := range targetBranches {
+		if out, err := git(repo, "rev-parse", "--verify", "--quiet",
+			branch+"^{commit}"); err == nil {
+			return strings.TrimSpace(string(out)), nil
+		}
+	}
+	return "", nil
+}
+
+// findFixes looks, for each frame of the panicking goroutine, for the
+// commits between the crash revision and the target that changed the line
+// or the function of the frame. Each commit is diffed with its first parent,
+// so changes merged from other branches are attributed to their merge.
+// The target is a revision of the main repository, so only its frames are
+// looked at.
+func (d *Dump) findFixes() {
+	if d.Target == "" || d.Target == d.Revision {
+		return
+	}
+	main := d.repositories.all[0]
+	type frame struct {
+		source         string
+		line, function lineRange
+	}
+	var paths []string
+	frames := map[string][]*frame{}
+	seen := map[string]bool{}
+	for i := range d.Buckets {
+		b := &d.Buckets[i]
+		if !b.First() {
+			continue
+		}
+		for j := range b.Stack.Calls {
+			c := &b.Stack.Calls[j]
+			path := d.mainPath(c)
+			if path == "" || c.IsStdlib() || seen[c.FullSourceLine()] {
+				continue
+			}
+			seen[c.FullSourceLine()] = true
+			f := &frame{
+				source:   c.FullSourceLine(),
+				line:     lineRange{c.Line, c.Line},
+				function: lineRange{c.Line, c.Line},
+			}
+			if start, end, err := functionRange(main, c, path); err == nil {
+				f.function = lineRange{start, end}
+			}
+			if _, ok := frames[path]; !ok {
+				paths = append(paths, path)
+			}
+			frames[path] = append(frames[path], f)
+		}
+	}
+
+	for _, path := range paths {
+		out, err := git(main.Path, "log", "--reverse",
+			"--first-parent", "-m", "-p", "-U0", "--no-color", "--no-ext-diff",
+			"--format=%x00%H", d.Revision+".."+d.Target, "--", path)
+		if err != nil {
+			continue
+		}
+		ids, patches := splitPatches(out)
+		for i, id := range ids {
+			hunks := parseHunks(patches[i])
+			for _, f := range frames[path] {
+				if f.line.deleted() && f.function.deleted() {
+					continue
+				}
+				var lineChanged, functionChanged bool
+				if !f.line.deleted() {
+					f.line, lineChanged = f.line.through(hunks)
+				}
+				if !f.function.deleted() {
+					f.function, functionChanged = f.function.through(hunks)
+				}
+				if !lineChanged && !functionChanged {
+					continue
+				}
+				cm, err := d.Commit(main, id)
+				if err != nil {
+					continue
+				}
+				fix := d.Fixes[f.source]
+				if fix == nil {
+					fix = &Fix{}
+					d.Fixes[f.source] = fix
+				}
+				if lineChanged {
+					fix.Line = append(fix.Line, cm)
+				} else {
+					fix.Function = append(fix.Function, cm)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/maruel/panicparse/stack"
+)
+
+// AuthorShare is the number of changes an author made to a function.
+type AuthorShare struct {
+	Author  Identity
+	Commits int
+	Newest  time.Time
+}
+
+// FunctionHistory lists the commits that changed a function, up to the crash
+// revision.
+type FunctionHistory struct {
+	Function string
+	File     string
+	// Start and End are the lines of the function at the crash revision.
+	Start, End int
+	// Commits are newest first.
+	Commits []*Commit
+	// Authors are the ones who changed the function most first.
+	Authors []AuthorShare
+}
+
+var reTypeParams = regexp.MustCompile(`\[[^\]]*\]`)
+
+// splitFuncName returns the receiver type, if any, and the name of the
+// top-level function declaring the function as named in the stack trace,
+// e.g. "T" and "Method" for "(*T).Method.func1".
+func splitFuncName(name string) (recv, fn string) {
+	name = reTypeParams.ReplaceAllString(name, "")
+	parts := strings.Split(name, ".")
+	if len(parts) > 1 && strings.HasPrefix(parts[0], "(") {
+		return strings.Trim(parts[0], "(*)"), parts[1]
+	}
+	return "", parts[0]
+}
+
+// receiverName returns the name of the type of the receiver of the method.
+func receiverName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return ""
+	}
+	t := decl.Recv.List[0].Type
+	for {
+		switch e := t.(type) {
+		case *ast.StarExpr:
+			t = e.X
+		case *ast.IndexExpr:
+			t = e.X
+		case *ast.IndexListExpr:
+			t = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// Call returns the call at the line of the file, or nil if there is none in
+// the dump.
+func (d *Dump) Call(file string, line int) *stack.Call {
+	for i := range d.Buckets {
+		calls := d.Buckets[i].Stack.Calls
+		for j := range calls {
+			if calls[j].SourcePath == file && calls[j].Line == line {
+				return &calls[j]
+			}
+		}
+	}
+	return nil
+}
+
+// functionRange returns the lines of the function called by the frame, in
+// the file of the repository at the revision blamed. If the name does not
+// match any declaration the one containing the line of the frame is used.
+func functionRange(repo *Repository, c *stack.Call, path string) (start,
+	end int, err error) {
+	src, err := git(repo.Path, "show", repo.Revision+":"+path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s not found at %.7s", path, repo.Revision)
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, src, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	recv, fn := splitFuncName(c.Func.Name())
+	var containing *ast.FuncDecl
+	for _, decl := range f.Decls {
+		decl, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		start := fset.Position(decl.Pos()).Line
+		end := fset.Position(decl.End()).Line
+		if decl.Name.Name == fn && receiverName(decl) == recv {
+			return start, end, nil
+		}
+		if start <= c.Line && c.Line <= end {
+			containing = decl
+		}
+	}
+	if containing == nil {
+		return 0, 0, fmt.Errorf("Function %s not found in %s", c.Func.Name(), path)
+	}
+	return fset.Position(containing.Pos()).Line,
+		fset.Position(containing.End()).Line, nil
+}
+
+// FunctionHistory returns the commits that changed the function called by
+// the frame.
+func (d *Dump) FunctionHistory(c *stack.Call) (*FunctionHistory, error) {
+	repo, path := d.locate(c)
+	if repo == nil {
+		return nil, fmt.Errorf("%s is not in the repository", c.SourcePath)
+	}
+	start, end, err := functionRange(repo, c, path)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := d.commitsFromLog(repo, "log", "-s", "--format=%x00%H",
+		fmt.Sprintf("-L%d,%d:%s", start, end, path), repo.Revision)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &FunctionHistory{
+		Function: c.Func.PkgDotName(),
+		File:     path,
+		Start:    start,
+		End:      end,
+		Commits:  commits,
+	}
+	shares := map[string]*AuthorShare{}
+	for _, cm := range commits {
+		key := strings.ToLower(cm.Email)
+		share, ok := shares[key]
+		if !ok {
+			share = &AuthorShare{Author: cm.Identity(), Newest: cm.Date}
+			shares[key] = share
+		}
+		share.Commits++
+		if cm.Date.After(share.Newest) {
+			share.Newest = cm.Date
+		}
+	}
+	for _, share := range shares {
+		h.Authors = append(h.Authors, *share)
+	}
+	sort.Slice(h.Authors, func(i, j int) bool {
+		a, b := &h.Authors[i], &h.Authors[j]
+		if a.Commits != b.Commits {
+			return a.Commits > b.Commits
+		}
+		return a.Newest.After(b.Newest)
+	})
+	return h, nil
+}
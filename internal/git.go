@@ -0,0 +1,15 @@
+package internal
+
+import (
+	"io/ioutil"
+	"os/exec"
+)
+
+// git runs a git command in the given repository and returns its standard
+// output. Standard error is discarded.
+func git(repo string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo
+	cmd.Stderr = ioutil.Discard
+	return cmd.Output()
+}
@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/maruel/panicparse/stack"
+)
+
+// Commit returns the commit with the given ID, loading it from the
+// repository if it was not blamed.
+func (d *Dump) Commit(repo *Repository, id string) (*Commit, error) {
+	if cm, ok := d.Commits.ByID[id]; ok {
+		return cm, nil
+	}
+	cm, err := LoadCommit(repo.Path, id)
+	if err != nil {
+		return nil, err
+	}
+	cm.Repository = repo.Name
+	d.resolveIdentities(&cm)
+	return d.Commits.Index(cm), nil
+}
+
+// commitsFromLog loads the commits listed by a git log command run in the
+// repository, which must print each of them as a NUL character followed by
+// the commit ID.
+func (d *Dump) commitsFromLog(repo *Repository, args ...string) ([]*Commit,
+	error) {
+	out, err := git(repo.Path, args...)
+	if err != nil {
+		return nil, err
+	}
+	var commits []*Commit
+	for _, line := range strings.Split(string(out), "\n") {
+		if !strings.HasPrefix(line, "\x00") {
+			continue
+		}
+		cm, err := d.Commit(repo, line[1:])
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, cm)
+	}
+	return commits, nil
+}
+
+// LineHistory returns all the commits that changed the line of the file, up
+// to the crash revision, newest first.
+func (d *Dump) LineHistory(file string, line int) ([]*Commit, error) {
+	repo, path := d.locate(&stack.Call{SourcePath: file, Line: line})
+	if repo == nil {
+		return nil, fmt.Errorf("%s is not in the repository", file)
+	}
+	return d.commitsFromLog(repo, "log", "-s", "--format=%x00%H",
+		fmt.Sprintf("-L%[1]d,%[1]d:%[2]s", line, path), repo.Revision)
+}
@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Identity is a person as recorded in the history: a name and an e-mail.
+type Identity struct {
+	Name  string `yaml:"name,omitempty"`
+	Email string `yaml:"email,omitempty"`
+}
+
+func (id Identity) String() string {
+	if id.Email == "" {
+		return id.Name
+	}
+	return fmt.Sprintf("%s <%s>", id.Name, id.Email)
+}
+
+// parseIdentity parses "Name <e-mail>" as printed by git.
+func parseIdentity(s string) Identity {
+	open, end := strings.LastIndex(s, "<"), strings.LastIndex(s, ">")
+	if open < 0 || end < open {
+		return Identity{Name: strings.TrimSpace(s)}
+	}
+	return Identity{
+		Name:  strings.TrimSpace(s[:open]),
+		Email: strings.TrimSpace(s[open+1 : end]),
+	}
+}
+
+// Alias maps alternative names and e-mails of a person onto their canonical
+// identity.
+type Alias struct {
+	Identity `yaml:",inline"`
+	Aliases  []string `yaml:"aliases,omitempty"`
+}
+
+func (a *Alias) matches(id Identity) bool {
+	for _, alias := range a.Aliases {
+		if strings.EqualFold(alias, id.Email) || alias == id.Name {
+			return true
+		}
+	}
+	return strings.EqualFold(a.Email, id.Email)
+}
+
+// mailmap resolves identities found in the history to their canonical form,
+// first through the repository's .mailmap and then through the aliases from
+// the configuration.
+type mailmap struct {
+	repo     string
+	revision string
+	aliases  []Alias
+
+	mu    sync.Mutex
+	cache map[Identity]Identity
+}
+
+func newMailmap(repo, revision string, aliases []Alias) *mailmap {
+	return &mailmap{
+		repo:     repo,
+		revision: revision,
+		aliases:  aliases,
+		cache:    map[Identity]Identity{},
+	}
+}
+
+// Resolve returns the canonical identity of the given person.
+func (m *mailmap) Resolve(id Identity) Identity {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if found, ok := m.cache[id]; ok {
+		return found
+	}
+
+	resolved := m.checkMailmap(id)
+	for i := range m.aliases {
+		a := &m.aliases[i]
+		if a.matches(id) || a.matches(resolved) {
+			if a.Name != "" {
+				resolved.Name = a.Name
+			}
+			if a.Email != "" {
+				resolved.Email = a.Email
+			}
+			break
+		}
+	}
+	m.cache[id] = resolved
+	return resolved
+}
+
+// checkMailmap resolves the identity through the .mailmap of the repository,
+// as recorded at the crash revision.
+func (m *mailmap) checkMailmap(id Identity) Identity {
+	if m.repo == "" || id.Email == "" {
+		return id
+	}
+	out, err := git(m.repo,
+		"-c", "mailmap.blob="+m.revision+":.mailmap",
+		"check-mailmap", id.String())
+	if err != nil {
+		return id
+	}
+	resolved := parseIdentity(strings.TrimSpace(string(out)))
+	if resolved.Email == "" {
+		return id
+	}
+	return resolved
+}
+
+// ResolveAll replaces the identities with their canonical forms.
+func (m *mailmap) ResolveAll(ids []Identity) {
+	for i := range ids {
+		ids[i] = m.Resolve(ids[i])
+	}
+}
+
+// Emails returns the canonical e-mail of the person followed by the other
+// ones they are known under: their aliases and the e-mails resolved so far.
+func (m *mailmap) Emails(id Identity) []string {
+	resolved := m.Resolve(id)
+	emails := []string{resolved.Email}
+	seen := map[string]bool{strings.ToLower(resolved.Email): true}
+	add := func(email string) {
+		if email != "" && !seen[strings.ToLower(email)] {
+			seen[strings.ToLower(email)] = true
+			emails = append(emails, email)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for raw, r := range m.cache {
+		if strings.EqualFold(r.Email, resolved.Email) {
+			add(raw.Email)
+		}
+	}
+	for i := range m.aliases {
+		a := &m.aliases[i]
+		if !a.matches(resolved) {
+			continue
+		}
+		add(a.Email)
+		for _, alias := range a.Aliases {
+			if strings.Contains(alias, "@") {
+				add(alias)
+			}
+		}
+	}
+	return emails
+}
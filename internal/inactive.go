@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Inactivity configures when an author is considered to have left: when they
+// are listed as alumni (directly or in AlumniFile, one e-mail per line), or
+// when they have not committed to the repository for Days days.
+type Inactivity struct {
+	Days       int      `yaml:"days,omitempty"`
+	Alumni     []string `yaml:"alumni,omitempty"`
+	AlumniFile string   `yaml:"alumni_file,omitempty"`
+}
+
+// Fallback is the person suggested in place of an inactive author.
+type Fallback struct {
+	// Author is the inactive author of the blamed commit.
+	Author Identity
+	// Recipient is who to ask instead; for a code owner only the name is set.
+	Recipient Identity
+	// Reason explains why Recipient was picked.
+	Reason string
+}
+
+// inactivity answers whether authors are inactive and who should be asked
+// instead, caching the answers.
+type inactivity struct {
+	repo   string
+	days   int
+	alumni map[string]bool
+
+	mu       sync.Mutex
+	inactive map[string]bool
+}
+
+func newInactivity(repo string, rule Inactivity) (*inactivity, error) {
+	in := &inactivity{
+		repo:     repo,
+		days:     rule.Days,
+		alumni:   map[string]bool{},
+		inactive: map[string]bool{},
+	}
+	for _, email := range rule.Alumni {
+		in.alumni[strings.ToLower(email)] = true
+	}
+	if rule.AlumniFile != "" {
+		f, err := os.Open(rule.AlumniFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && line[0] != '#' {
+				in.alumni[strings.ToLower(line)] = true
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return in, nil
+}
+
+// enabled returns whether any inactivity rule is configured.
+func (in *inactivity) enabled() bool {
+	return in.days > 0 || len(in.alumni) > 0
+}
+
+// Inactive returns whether the author with the given e-mails, the canonical
+// one first and then its aliases, has left. An author without any commit
+// under these e-mails is not known to have left.
+func (in *inactivity) Inactive(emails ...string) bool {
+	var quoted []string
+	for _, email := range emails {
+		email = strings.ToLower(email)
+		if email == "" {
+			continue
+		}
+		if in.alumni[email] {
+			return true
+		}
+		quoted = append(quoted, regexp.QuoteMeta(email))
+	}
+	if len(quoted) == 0 || in.days <= 0 {
+		return false
+	}
+
+	key := strings.ToLower(emails[0])
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if inactive, ok := in.inactive[key]; ok {
+		return inactive
+	}
+	inactive := false
+	out, err := git(in.repo, "log", "-1", "--all", "--format=%at",
+		"--regexp-ignore-case", "--extended-regexp",
+		"--author=<("+strings.Join(quoted, "|")+")>")
+	if date := strings.TrimSpace(string(out)); err == nil && date != "" {
+		last, err := strconv.ParseInt(date, 10, 64)
+		inactive = err == nil &&
+			time.Since(time.Unix(last, 0)) > time.Duration(in.days)*24*time.Hour
+	}
+	in.inactive[key] = inactive
+	return inactive
+}
+
+// since returns the period in which committers are considered recent.
+func (in *inactivity) since() string {
+	days := in.days
+	if days <= 0 {
+		days = 90
+	}
+	return fmt.Sprintf("--since=%d days ago", days)
+}
+
+// mostActive returns the active person who committed most to the path of the
+// repository recently, up to its revision, other than the given author.
+func (in *inactivity) mostActive(repo *Repository, path string,
+	author Identity) (Identity, bool) {
+	out, err := git(repo.Path, "shortlog", "-sne", "--use-mailmap", in.since(),
+		repo.Revision, "--", path)
+	if err != nil {
+		return Identity{}, false
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		tab := strings.Index(line, "\t")
+		if tab < 0 {
+			continue
+		}
+		id := parseIdentity(line[tab+1:])
+		if !strings.EqualFold(id.Email, author.Email) && !in.Inactive(id.Email) {
+			return id, true
+		}
+	}
+	return Identity{}, false
+}
+
+// Fallback suggests whom to ask about the file instead of the inactive
+// author: the most active recent committer to the file, then to its
+// directory, then the first code owner. The recipient is left empty if there
+// is nobody to suggest.
+func (in *inactivity) Fallback(repo *Repository, author Identity, file string,
+	owners []string) *Fallback {
+	fb := &Fallback{Author: author}
+	if id, ok := in.mostActive(repo, file, author); ok {
+		fb.Recipient = id
+		fb.Reason = "most active recent committer to " + filepath.Base(file)
+		return fb
+	}
+	dir := filepath.Dir(file)
+	if id, ok := in.mostActive(repo, dir, author); ok {
+		fb.Recipient = id
+		fb.Reason = "most active recent committer to " + dir + "/"
+		return fb
+	}
+	if len(owners) > 0 {
+		fb.Recipient = Identity{Name: owners[0]}
+		fb.Reason = "code owner"
+	}
+	return fb
+}
@@ -0,0 +1,199 @@
+package internal
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hunk is a change of a diff, in the lines of the old and new file. A hunk
+// adding lines has no old lines and starts after OldStart; one removing
+// lines has no new lines.
+type hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+}
+
+var reHunk = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// parseHunks reads the hunk headers of a patch.
+func parseHunks(patch []string) []hunk {
+	var hunks []hunk
+	for _, line := range patch {
+		m := reHunk.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		count := func(s string) int {
+			if s == "" {
+				return 1
+			}
+			n, _ := strconv.Atoi(s)
+			return n
+		}
+		oldStart, _ := strconv.Atoi(m[1])
+		newStart, _ := strconv.Atoi(m[3])
+		hunks = append(hunks, hunk{oldStart, count(m[2]), newStart, count(m[4])})
+	}
+	return hunks
+}
+
+// lineRange is an inclusive range of lines of a file.
+type lineRange struct {
+	Start, End int
+}
+
+// through follows the range through the hunks of a diff. It returns where
+// the range is in the new file and whether the hunks changed any of its
+// lines. When they did the range is only approximated.
+func (r lineRange) through(hunks []hunk) (lineRange, bool) {
+	moved := r
+	changed := false
+	for _, h := range hunks {
+		delta := h.NewLines - h.OldLines
+		if h.OldLines == 0 {
+			// Lines inserted after OldStart.
+			switch {
+			case h.OldStart < r.Start:
+				moved.Start += delta
+				moved.End += delta
+			case h.OldStart < r.End:
+				moved.End += delta
+				changed = true
+			}
+			continue
+		}
+		oldEnd := h.OldStart + h.OldLines - 1
+		switch {
+		case oldEnd < r.Start:
+			moved.Start += delta
+			moved.End += delta
+		case h.OldStart <= r.End:
+			changed = true
+			if h.NewLines == 0 {
+				// The lines of the range in the hunk are gone.
+				if h.OldStart <= r.Start {
+					moved.Start = h.NewStart + 1
+				}
+				if oldEnd >= r.End {
+					moved.End = h.NewStart
+				} else {
+					moved.End += delta
+				}
+				continue
+			}
+			// The lines of the range in the hunk are somewhere in its new
+			// lines.
+			newEnd := h.NewStart + h.NewLines - 1
+			if h.OldStart <= r.Start {
+				moved.Start = clamp(moved.Start, h.NewStart, newEnd)
+			}
+			if oldEnd >= r.End {
+				moved.End = clamp(moved.End, h.NewStart, newEnd)
+			} else {
+				moved.End += delta
+			}
+		}
+	}
+	if moved.End < moved.Start {
+		moved.End = moved.Start - 1
+	}
+	return moved, changed
+}
+
+func clamp(n, min, max int) int {
+	switch {
+	case n < min:
+		return min
+	case n > max:
+		return max
+	}
+	return n
+}
+
+// deleted tells whether the range has no line left.
+func (r lineRange) deleted() bool {
+	return r.End < r.Start
+}
+
+// splitPatches splits the output of git log -p, run with a format printing
+// a NUL character followed by the commit ID, into the patch of each commit.
+func splitPatches(out []byte) (ids []string, patches [][]string) {
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "\x00") {
+			ids = append(ids, line[1:])
+			patches = append(patches, nil)
+		} else if len(patches) > 0 {
+			patches[len(patches)-1] = append(patches[len(patches)-1], line)
+		}
+	}
+	return ids, patches
+}
+
+// Line statuses of a stack line in the working tree, compared to the crash
+// revision.
+const (
+	LineModified = "modified"
+	LineDeleted  = "deleted"
+)
+
+// MappedLine is where a stack line of the crash revision is now.
+type MappedLine struct {
+	// Head and Local are the line at HEAD and in the working tree,
+	// uncommitted changes included; they are 0 if the line was deleted.
+	Head, Local int
+	// Status tells whether the line is modified or deleted in the working
+	// tree, or is empty if the line is unchanged.
+	Status string
+}
+
+// mapLine follows the line of the file of the repository through the diff
+// between the revision blamed and the given revisions, or the working tree if
+// there are none. It returns 0 if the line was deleted.
+func mapLine(repo *Repository, path string, line int, revisions ...string) (
+	int, bool, error) {
+	args := append([]string{"diff", "-U0", "--no-color", "--no-ext-diff",
+		repo.Revision}, revisions...)
+	out, err := git(repo.Path, append(args, "--", path)...)
+	if err != nil {
+		return 0, false, err
+	}
+	mapped, changed := lineRange{line, line}.through(
+		parseHunks(strings.Split(string(out), "\n")))
+	if mapped.deleted() {
+		return 0, true, nil
+	}
+	return mapped.Start, changed, nil
+}
+
+// mapLines maps the stack lines of the dump to HEAD and to the working tree.
+func (d *Dump) mapLines() {
+	for i := range d.Buckets {
+		for j := range d.Buckets[i].Stack.Calls {
+			c := &d.Buckets[i].Stack.Calls[j]
+			repo, path := d.locate(c)
+			if repo == nil {
+				continue
+			}
+			if _, ok := d.Lines[c.FullSourceLine()]; ok {
+				continue
+			}
+			head, _, err := mapLine(repo, path, c.Line, "HEAD")
+			if err != nil {
+				continue
+			}
+			local, changed, err := mapLine(repo, path, c.Line)
+			if err != nil {
+				continue
+			}
+			m := &MappedLine{Head: head, Local: local}
+			switch {
+			case local == 0:
+				m.Status = LineDeleted
+			case changed:
+				m.Status = LineModified
+			}
+			d.Lines[c.FullSourceLine()] = m
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package internal
+
+import "testing"
+
+func TestLineRangeThrough(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       lineRange
+		hunks   []hunk
+		want    lineRange
+		changed bool
+	}{
+		{"insert before", lineRange{13, 13}, []hunk{{5, 0, 6, 2}},
+			lineRange{15, 15}, false},
+		{"insert after", lineRange{13, 13}, []hunk{{13, 0, 14, 1}},
+			lineRange{13, 13}, false},
+		{"insert inside", lineRange{10, 13}, []hunk{{11, 0, 12, 2}},
+			lineRange{10, 15}, true},
+		{"delete before", lineRange{13, 13}, []hunk{{3, 2, 2, 0}},
+			lineRange{11, 11}, false},
+		{"delete line", lineRange{13, 13}, []hunk{{13, 1, 12, 0}},
+			lineRange{13, 12}, true},
+		{"delete inside", lineRange{10, 20}, []hunk{{15, 2, 14, 0}},
+			lineRange{10, 18}, true},
+		{"delete around", lineRange{10, 12}, []hunk{{9, 5, 8, 0}},
+			lineRange{9, 8}, true},
+		{"delete end", lineRange{10, 13}, []hunk{{12, 4, 11, 0}},
+			lineRange{10, 11}, true},
+		{"shrink", lineRange{13, 13}, []hunk{{10, 5, 10, 2}},
+			lineRange{11, 11}, true},
+		{"shrink start", lineRange{13, 20}, []hunk{{10, 5, 10, 2}},
+			lineRange{11, 17}, true},
+		{"grow before", lineRange{13, 13}, []hunk{{10, 2, 10, 5}},
+			lineRange{16, 16}, false},
+		{"grow around", lineRange{13, 13}, []hunk{{12, 2, 12, 5}},
+			lineRange{13, 13}, true},
+		{"modify after", lineRange{13, 13}, []hunk{{20, 1, 20, 1}},
+			lineRange{13, 13}, false},
+		{"insert then shrink", lineRange{13, 13},
+			[]hunk{{1, 0, 2, 2}, {10, 5, 12, 2}}, lineRange{13, 13}, true},
+	}
+	for _, test := range tests {
+		got, changed := test.r.through(test.hunks)
+		if got != test.want || changed != test.changed {
+			t.Errorf("%s: %v through %v = %v, %v; want %v, %v", test.name,
+				test.r, test.hunks, got, changed, test.want, test.changed)
+		}
+	}
+}
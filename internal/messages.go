@@ -20,13 +20,23 @@ type Palette struct {
 
 	Routine      string `yaml:"routine,omitempty"`
 	RoutineFirst string `yaml:"routine_first,omitempty"`
+	Panic        string `yaml:"panic,omitempty"`
 
 	Package    string `yaml:"package,omitempty"`
 	SourceFile string `yaml:"source_file,omitempty"`
 	Arguments  string `yaml:"arguments,omitempty"`
 
-	CommitID   string `yaml:"commit_id,omitempty"`
-	CommitDate string `yaml:"commit_date,omitempty"`
+	CommitID    string `yaml:"commit_id,omitempty"`
+	CommitDate  string `yaml:"commit_date,omitempty"`
+	Uncommitted string `yaml:"uncommitted,omitempty"`
+
+	Score       string `yaml:"score,omitempty"`
+	LineChanged string `yaml:"line_changed,omitempty"`
+
+	DiffHeader  string `yaml:"diff_header,omitempty"`
+	DiffHunk    string `yaml:"diff_hunk,omitempty"`
+	DiffAdded   string `yaml:"diff_added,omitempty"`
+	DiffRemoved string `yaml:"diff_removed,omitempty"`
 }
 
 func DefaultPalette() Palette {
@@ -39,13 +49,23 @@ func DefaultPalette() Palette {
 
 		Routine:      "fg-magenta",
 		RoutineFirst: "fg-magenta,fg-bold",
+		Panic:        "fg-red,fg-bold",
 
 		Package:    "fg-white,fg-bold",
 		SourceFile: "fg-white",
 		Arguments:  "fg-white",
 
-		CommitID:   "fg-white,fg-bold",
-		CommitDate: "fg-white",
+		CommitID:    "fg-white,fg-bold",
+		CommitDate:  "fg-white",
+		Uncommitted: "fg-cyan,fg-bold",
+
+		Score:       "fg-yellow,fg-bold",
+		LineChanged: "fg-yellow",
+
+		DiffHeader:  "fg-white,fg-bold",
+		DiffHunk:    "fg-cyan",
+		DiffAdded:   "fg-green",
+		DiffRemoved: "fg-red",
 	}
 }
 
@@ -59,28 +79,88 @@ type Format struct {
 
 	Colors Palette `yaml:"colors,omitempty"`
 
+	// Hosts are the URL templates of the frames of dependencies blamed in
+	// their own repository, by the host of their module path, e.g.
+	// "github.com".
+	Hosts map[string]URLs `yaml:"hosts,omitempty"`
+
+	// Repositories are the URL templates of the frames from the other
+	// repositories of the Source, submodules included, by name.
+	Repositories map[string]URLs `yaml:"repositories,omitempty"`
+
 	templates struct {
-		CommitURL *template.Template
-		FileURL   *template.Template
-		BlameURL  *template.Template
-		Message   *template.Template
+		urlTemplates
+		Message      *template.Template
+		hosts        map[string]*urlTemplates
+		repositories map[string]*urlTemplates
 	}
 }
 
-func (f *Format) Init() error {
+// URLs are the templates of the links to a commit, a file and its blame.
+type URLs struct {
+	CommitURL string `yaml:"commit_url,omitempty"`
+	FileURL   string `yaml:"file_url,omitempty"`
+	BlameURL  string `yaml:"blame_url,omitempty"`
+}
+
+type urlTemplates struct {
+	CommitURL *template.Template
+	FileURL   *template.Template
+	BlameURL  *template.Template
+}
+
+func (u *URLs) parse(t *urlTemplates) error {
 	var err error
-	f.templates.CommitURL, err = template.New("CommitURL").Parse(f.CommitURL)
+	t.CommitURL, err = template.New("CommitURL").Parse(u.CommitURL)
 	if err != nil {
 		return err
 	}
-	f.templates.FileURL, err = template.New("FileURL").Parse(f.FileURL)
+	t.FileURL, err = template.New("FileURL").Parse(u.FileURL)
 	if err != nil {
 		return err
 	}
-	f.templates.BlameURL, err = template.New("BlameURL").Parse(f.BlameURL)
-	if err != nil {
+	t.BlameURL, err = template.New("BlameURL").Parse(u.BlameURL)
+	return err
+}
+
+// urls returns the URL templates for the frame: the ones of its repository
+// if it is not the main one, or of the host of its module if it was blamed in
+// the repository of a dependency.
+func (f *Format) urls(file *SourcePath) *urlTemplates {
+	if t, ok := f.templates.repositories[file.Repository]; ok {
+		return t
+	}
+	if dep := file.Dependency; dep != nil && dep.Upstream != "" {
+		host := strings.SplitN(dep.Module, "/", 2)[0]
+		if t, ok := f.templates.hosts[host]; ok {
+			return t
+		}
+	}
+	return &f.templates.urlTemplates
+}
+
+func (f *Format) Init() error {
+	var err error
+	urls := URLs{f.CommitURL, f.FileURL, f.BlameURL}
+	if err := urls.parse(&f.templates.urlTemplates); err != nil {
 		return err
 	}
+	f.templates.hosts = map[string]*urlTemplates{}
+	for host, urls := range f.Hosts {
+		t := &urlTemplates{}
+		if err := urls.parse(t); err != nil {
+			return err
+		}
+		f.templates.hosts[host] = t
+	}
+	f.templates.repositories = map[string]*urlTemplates{}
+	for name, urls := range f.Repositories {
+		t := &urlTemplates{}
+		if err := urls.parse(t); err != nil {
+			return err
+		}
+		f.templates.repositories[name] = t
+	}
 	if f.CustomMessage == "" {
 		f.templates.Message, err = message.Clone()
 	} else {
@@ -106,19 +186,135 @@ func (f *Format) format(t *template.Template, v interface{}) string {
 }
 
 func (f *Format) Commits(c []*Commit) []string {
+	p := &f.Colors
 	commits := make([]string, len(c))
 	for i := range c {
-		commits[i] =
-			fmt.Sprintf(`[%s] %s`, c[i].Date.Format("2006-02-01 15:04:05"), c[i].ID)
+		commits[i] = fmt.Sprintf("{%s @ %s} %s: %s",
+			colorf(p.CommitDate, "%s", c[i].Date.Format("2006-01-02 15:04:05")),
+			colorf(p.CommitID, "%.7s", c[i].ID),
+			c[i].Author, c[i].Message)
 	}
 	return commits
 }
 
+// Suspects formats the ranked suspects, each with its score breakdown.
+func (f *Format) Suspects(s []Suspect) []string {
+	p := &f.Colors
+	suspects := make([]string, len(s))
+	for i := range s {
+		signals := make([]string, len(s[i].Signals))
+		for j, signal := range s[i].Signals {
+			signals[j] = fmt.Sprintf("%s %.1f", signal.Name, signal.Score)
+		}
+		c := s[i].Commit
+		suspects[i] = fmt.Sprintf("%s {%s @ %s} %s: %s (%s)",
+			colorf(p.Score, "%4.1f", s[i].Score),
+			colorf(p.CommitDate, "%s", c.Date.Format("2006-01-02")),
+			colorf(p.CommitID, "%.4s", c.ID),
+			c.Author, c.Message, strings.Join(signals, ", "))
+	}
+	return suspects
+}
+
+// AuthorSummary prints the aggregated frames of an author.
+func (f *Format) AuthorSummary(a *AuthorSummary) string {
+	return fmt.Sprintf("%s: %d frames, %d commits, newest %s, packages %s",
+		a.Author, len(a.Frames), len(a.Commits),
+		colorf(f.Colors.CommitDate, "%s", a.Newest.Format("2006-01-02")),
+		colorf(f.Colors.Package, "%s", strings.Join(a.Packages, ", ")))
+}
+
+// AuthorShare prints the changes of an author to a function.
+func (f *Format) AuthorShare(a *AuthorShare) string {
+	return fmt.Sprintf("  %s: %d changes, newest %s", a.Author, a.Commits,
+		colorf(f.Colors.CommitDate, "%s", a.Newest.Format("2006-01-02")))
+}
+
+// TeamSummary prints the aggregated frames of a team.
+func (f *Format) TeamSummary(t *TeamSummary) string {
+	team := t.Team
+	if team == "" {
+		team = "(no team)"
+	}
+	return fmt.Sprintf("%s: %d frames, %d commits, newest %s",
+		colorf(f.Colors.RoutineFirst, "%s", team), t.Frames, t.Commits,
+		colorf(f.Colors.CommitDate, "%s", t.Newest.Format("2006-01-02")))
+}
+
+// Frames prints the given stack lines, as in the stack trace.
+func (f *Format) Frames(d *Dump, calls []stack.Call) []string {
+	srcLen := 0
+	for _, c := range calls {
+		if l := len(c.SourceLine()); l > srcLen {
+			srcLen = l
+		}
+	}
+	lines := make([]string, len(calls))
+	for i := range calls {
+		lines[i] = f.callLine(&calls[i],
+			d.Commits.BySource[calls[i].FullSourceLine()], srcLen)
+	}
+	return lines
+}
+
+// Diff colors the lines of a patch.
+func (f *Format) Diff(patch []string) []string {
+	p := &f.Colors
+	lines := make([]string, len(patch))
+	for i, line := range patch {
+		line = strings.Replace(line, "\t", "    ", -1)
+		color := ""
+		switch {
+		case strings.HasPrefix(line, "diff "), strings.HasPrefix(line, "index "),
+			strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "):
+			color = p.DiffHeader
+		case strings.HasPrefix(line, "@@"):
+			color = p.DiffHunk
+		case strings.HasPrefix(line, "+"):
+			color = p.DiffAdded
+		case strings.HasPrefix(line, "-"):
+			color = p.DiffRemoved
+		}
+		if line == "" {
+			color = ""
+		}
+		lines[i] = colorf(color, "%s", line)
+	}
+	return lines
+}
+
 type SourcePath struct {
 	Head     string
 	File     string
 	Line     int
 	CommitID string
+	Owners   []string
+	Fallback *Fallback
+	// Fixes are the later commits that may have fixed the frame.
+	Fixes *Fix
+	// HeadLine and LocalLine are where Line is at HEAD and in the working
+	// tree, 0 if it was deleted, and LineStatus tells whether it was
+	// modified or deleted since the crash revision.
+	HeadLine   int
+	LocalLine  int
+	LineStatus string
+	// Dependency is the module of the frame if it is not in the code of the
+	// repository, in which case the commit is the one that changed its
+	// version.
+	Dependency *Dependency
+	// Repository names the repository of the frame when it is not the main
+	// one, and Path is the path of the file in it; Head is then its
+	// revision.
+	Repository string
+	Path       string
+}
+
+// Frame is a single stack frame together with the commit it is blamed on, as
+// shown in the commit panel. Either part may be empty.
+type Frame struct {
+	SourcePath
+	Commit *Commit
+	Person *Person
 }
 
 // functionColor returns the color to be used for the function name based on
@@ -180,44 +376,86 @@ func colorf(color, format string, a ...interface{}) string {
 	return fmt.Sprintf("[%s](%s)", s, color)
 }
 
+// sourceLine returns the file and line of the call, in full if configured.
+func (f *Format) sourceLine(line *stack.Call) string {
+	if f.FullPath {
+		return line.FullSourceLine()
+	}
+	return line.SourceLine()
+}
+
 // callLine prints one stack line.
 func (f *Format) callLine(line *stack.Call, commit *Commit, srcLen int) string {
 	p := &f.Colors
 	id := "????"
 	date := "????-??-??"
+	if commit != nil && commit.Uncommitted() {
+		return fmt.Sprintf("    {%s} %s  %s%s",
+			colorf(p.Uncommitted, "not committed yet"),
+			colorf(p.SourceFile, "%-*s", srcLen, f.sourceLine(line)),
+			colorf(f.functionColor(line), "%s", line.Func.Name()),
+			colorf(p.Arguments, "(%s)", line.Args))
+	}
 	if commit != nil {
 		id = commit.ID[:4]
 		date = commit.Date.Format("2006-01-02")
 	}
 
-	var srcLine string
-	if f.FullPath {
-		srcLine = line.FullSourceLine()
-	} else {
-		srcLine = line.SourceLine()
-	}
-
 	return fmt.Sprintf(
 		"    {%s @ %s} %s  %s%s",
 		colorf(p.CommitDate, "%s", date),
 		colorf(p.CommitID, "%s", id),
-		colorf(p.SourceFile, "%-*s", srcLen, srcLine),
+		colorf(p.SourceFile, "%-*s", srcLen, f.sourceLine(line)),
 		colorf(f.functionColor(line), "%s", line.Func.Name()),
 		colorf(p.Arguments, "(%s)", line.Args))
 }
 
+// lineChange marks the stack lines changed or moved in the working tree.
+func (f *Format) lineChange(m *MappedLine, line int) string {
+	switch {
+	case m.Status == LineDeleted:
+		return colorf(f.Colors.LineChanged, "  (line deleted)")
+	case m.Status == LineModified:
+		return colorf(f.Colors.LineChanged, "  (line modified, now :%d)", m.Local)
+	case m.Local != line:
+		return colorf(f.Colors.LineChanged, "  (now :%d)", m.Local)
+	}
+	return ""
+}
+
 // StackLines prints one complete stack trace, without the header.
-func (f *Format) StackLines(head string, signature *stack.Signature,
-	commits *Commits, srcLen int) ([]string, []SourcePath) {
+func (f *Format) StackLines(d *Dump, signature *stack.Signature,
+	srcLen int) ([]string, []SourcePath) {
 	lines := make([]string, len(signature.Stack.Calls))
 	files := make([]SourcePath, len(signature.Stack.Calls))
 	for i, c := range signature.Stack.Calls {
-		commit := commits.BySource[c.FullSourceLine()]
+		commit := d.Commits.BySource[c.FullSourceLine()]
 		lines[i] = f.callLine(&c, commit, srcLen)
-		files[i] = SourcePath{Head: head, File: c.SourcePath, Line: c.Line}
+		files[i] = SourcePath{
+			Head:       d.Revision,
+			File:       c.SourcePath,
+			Line:       c.Line,
+			Owners:     d.Owners[c.SourcePath],
+			Fallback:   d.Fallbacks[c.FullSourceLine()],
+			Fixes:      d.Fixes[c.FullSourceLine()],
+			Dependency: d.Dependencies[c.FullSourceLine()],
+			HeadLine:   c.Line,
+			LocalLine:  c.Line,
+		}
 		if commit != nil {
 			files[i].CommitID = commit.ID
 		}
+		if r, path := d.locate(&c); r != nil {
+			files[i].Repository, files[i].Path = r.Name, path
+			if r.Name != "" && r.Revision != "" {
+				files[i].Head = r.Revision
+			}
+		}
+		if m := d.Lines[c.FullSourceLine()]; m != nil {
+			files[i].HeadLine, files[i].LocalLine = m.Head, m.Local
+			files[i].LineStatus = m.Status
+			lines[i] += f.lineChange(m, c.Line)
+		}
 	}
 	if signature.Stack.Elided {
 		lines = append(lines, "    (...)")
@@ -226,9 +464,32 @@ func (f *Format) StackLines(head string, signature *stack.Signature,
 	return lines, files
 }
 
+// PanicHeader prints the reason of the crash, shown above the goroutine that
+// panicked.
+func (f *Format) PanicHeader(p *Panic) []string {
+	c := f.Colors.Panic
+	var lines []string
+	for i, msg := range p.Messages {
+		if i == 0 {
+			lines = append(lines, colorf(c, "%s: %s", strings.ToUpper(p.Kind), msg))
+		} else {
+			lines = append(lines, colorf(c, "  then %s: %s", p.Kind, msg))
+		}
+	}
+	if p.Signal != "" {
+		lines = append(lines, colorf(c, "SIGNAL: %s %s", p.Signal, p.SignalInfo))
+	}
+	if p.Goroutine != 0 && len(lines) > 0 {
+		lines = append(lines, colorf(c, "in goroutine %d:", p.Goroutine))
+	}
+	return lines
+}
+
+// Stacktrace prints the goroutines, the one that panicked first, followed by
+// the rest of the output of the program.
 func (f *Format) Stacktrace(d Dump) ([]string, []SourcePath) {
-	lines := strings.Split(d.Skipped, "\n")
-	files := make([]SourcePath, len(lines))
+	var lines []string
+	var files []SourcePath
 
 	srcLen, _ := stack.CalcLengths(d.Buckets, f.FullPath)
 	for _, bucket := range d.Buckets {
@@ -236,13 +497,25 @@ func (f *Format) Stacktrace(d Dump) ([]string, []SourcePath) {
 			lines = append(lines, "")
 			files = append(files, SourcePath{})
 		}
+		if bucket.First() {
+			header := f.PanicHeader(&d.Panic)
+			lines = append(lines, header...)
+			files = append(files, make([]SourcePath, len(header))...)
+		}
 		lines = append(lines, f.BucketHeader(&bucket, len(d.Buckets) > 1))
 		files = append(files, SourcePath{})
 		stackLines, stackFiles :=
-			f.StackLines(d.Revision, &bucket.Signature, &d.Commits, srcLen)
+			f.StackLines(&d, &bucket.Signature, srcLen)
 		lines = append(lines, stackLines...)
 		files = append(files, stackFiles...)
 	}
+
+	if d.Panic.Output != "" {
+		output := strings.Split(d.Panic.Output, "\n")
+		lines = append(lines, "", "Output:")
+		lines = append(lines, output...)
+		files = append(files, make([]SourcePath, len(output)+2)...)
+	}
 	return lines, files
 }
 
@@ -258,13 +531,22 @@ func (f *Format) StacktraceForMessage(d Dump) string {
 	return out.String()
 }
 
-func (f *Format) Commit(c *Commit) string {
-	return f.format(commit, c)
+func (f *Format) Commit(fr Frame) string {
+	return f.format(commit, fr)
 }
 
 type Candidate struct {
 	Dump   *Dump
 	Commit *Commit
+	// Commits are all the commits the message is about, when it is addressed
+	// to an author of several of them; Commit is the newest.
+	Commits []*Commit
+	// Owners are the code owners of the selected frame.
+	Owners []string
+	// Person is the directory entry of the commit author, if any.
+	Person *Person
+	// Fallback is whom to ask instead if the author has left.
+	Fallback *Fallback
 }
 
 func (f *Format) Message(c Candidate) string {
@@ -272,7 +554,7 @@ func (f *Format) Message(c Candidate) string {
 }
 
 var (
-	commit = template.Must(template.New("commit").Parse(
+	commit = template.Must(template.New("commit").Funcs(messageFuncs).Parse(
 		_escFSMustString(false, "/templates/commit.template")))
 	message = template.Must(template.New("message").
 		Funcs(messageFuncs).
@@ -281,5 +563,6 @@ var (
 		"replace": func(s, old, new string) string {
 			return strings.Replace(s, old, new, -1)
 		},
+		"join": strings.Join,
 	}
 )
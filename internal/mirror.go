@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// reSCP matches the scp-like clone URLs, e.g. git@github.com:owner/repo.
+var reSCP = regexp.MustCompile(`^[^/@:]+@[^/:]+:`)
+
+// isRemote tells whether the repository is a clone URL rather than a local
+// path.
+func isRemote(repo string) bool {
+	return strings.Contains(repo, "://") || reSCP.MatchString(repo)
+}
+
+// mirrorDir returns the directory of the mirror of the repository in the
+// cache: its base name, made unique by a hash of the URL.
+func mirrorDir(cache, url string) string {
+	name := strings.TrimSuffix(path.Base(strings.TrimRight(url, "/")), ".git")
+	return filepath.Join(cache,
+		fmt.Sprintf("%s-%.6x.git", name, sha1.Sum([]byte(url))))
+}
+
+// mirror clones the repository as a bare mirror in the cache, by default the
+// user's cache directory, and returns its path. An existing mirror is only
+// fetched when it lacks the revision.
+func mirror(url, cache, revision string) (string, error) {
+	if cache == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		cache = filepath.Join(dir, "iblameyou")
+	}
+	dir := mirrorDir(cache, url)
+
+	if _, err := os.Stat(dir); err != nil {
+		if err := os.MkdirAll(cache, 0755); err != nil {
+			return "", err
+		}
+		if err := gitVerbose(cache, "clone", "--quiet", "--mirror", url, dir); err != nil {
+			return "", fmt.Errorf("Failed to mirror %s:\n%s", url, err)
+		}
+		return dir, nil
+	}
+
+	if revision == "" {
+		revision = "HEAD"
+	}
+	if _, err := git(dir, "rev-parse", "--verify", "--quiet",
+		revision+"^{commit}"); err == nil {
+		return dir, nil
+	}
+	if err := gitVerbose(dir, "fetch", "--quiet", "--prune", "origin"); err != nil {
+		return "", fmt.Errorf("Failed to fetch %s:\n%s", url, err)
+	}
+	return dir, nil
+}
+
+// gitVerbose runs git, returning its error output as the error if it fails.
+func gitVerbose(dir string, args ...string) error {
+	stderr := new(bytes.Buffer)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s", msg)
+		}
+		return err
+	}
+	return nil
+}
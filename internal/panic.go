@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/maruel/panicparse/stack"
+)
+
+// Panic describes how the program crashed, as printed before the goroutines.
+type Panic struct {
+	// Kind is "panic" or "fatal error"; it is empty if the dump does not say
+	// why the program stopped.
+	Kind string
+	// Messages are the panic values or error messages, the first being the
+	// original one when the panic was recovered and panicked again.
+	Messages []string
+	// Signal is the signal received, e.g. "SIGSEGV: segmentation violation",
+	// and SignalInfo the details printed with it, e.g. "addr=0x0 pc=0x4a3f".
+	Signal     string
+	SignalInfo string
+	// Goroutine is the ID of the goroutine that panicked or received the
+	// signal, i.e. the first one in the dump.
+	Goroutine int
+	// Output is the rest of the text that is not part of the dump, e.g. the
+	// logs of the program.
+	Output string
+}
+
+var (
+	rePanic      = regexp.MustCompile(`^\s*(panic|fatal error): (.*?)(?: \[recovered\])?$`)
+	reSignal     = regexp.MustCompile(`^\[signal (SIG[A-Z]+[^\]]*?)(?: (code=.*))?\]$`)
+	reSignalDump = regexp.MustCompile(`^(SIG[A-Z]+: .*)$`)
+)
+
+// parsePanic extracts the panic from the text skipped by the dump parser.
+func parsePanic(skipped string, routines []stack.Goroutine) Panic {
+	var p Panic
+	var output []string
+	for _, line := range strings.Split(skipped, "\n") {
+		if m := rePanic.FindStringSubmatch(line); m != nil {
+			if p.Kind == "" {
+				p.Kind = m[1]
+			}
+			p.Messages = append(p.Messages, m[2])
+		} else if m := reSignal.FindStringSubmatch(line); m != nil {
+			p.Signal, p.SignalInfo = m[1], m[2]
+		} else if m := reSignalDump.FindStringSubmatch(line); m != nil {
+			p.Signal = m[1]
+		} else {
+			output = append(output, line)
+		}
+	}
+	p.Output = strings.TrimSpace(strings.Join(output, "\n"))
+	for _, r := range routines {
+		if r.First {
+			p.Goroutine = r.ID
+			break
+		}
+	}
+	return p
+}
+
+// Message returns the original panic value or error message.
+func (p *Panic) Message() string {
+	if len(p.Messages) == 0 {
+		return ""
+	}
+	return p.Messages[0]
+}
+
+// PanicFrame returns the first frame of the panicking goroutine that is not
+// in the runtime or the standard library, or nil if there is none.
+func (d *Dump) PanicFrame() *stack.Call {
+	for i := range d.Buckets {
+		b := &d.Buckets[i]
+		if !b.First() {
+			continue
+		}
+		for j := range b.Stack.Calls {
+			if c := &b.Stack.Calls[j]; !c.IsStdlib() {
+				return c
+			}
+		}
+	}
+	return nil
+}
@@ -2,11 +2,12 @@ package internal
 
 import (
 	"bytes"
+	"fmt"
 	"io"
-	"io/ioutil"
-	"os/exec"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/maruel/panicparse/stack"
 )
@@ -17,12 +18,130 @@ type Dump struct {
 	Commits  Commits
 	Skipped  string
 
-	source *Source
+	// RevisionDate is the commit date of the crash revision.
+	RevisionDate time.Time
+
+	// MissingRevision is the crash revision if the repository does not
+	// have it, in which case Revision is HEAD. Shallow tells whether the
+	// repository is a shallow clone, whose oldest commits get the blame for
+	// the older lines.
+	MissingRevision string
+	Shallow         bool
+
+	// Panic tells why the program crashed.
+	Panic Panic
+
+	// Owners lists the code owners of each source file in the dump, as
+	// given by the CODEOWNERS file at the crash revision.
+	Owners map[string][]string
+
+	// People is the directory used to find the teams and handles of the
+	// authors.
+	People Directory
+
+	// Fallbacks holds, for each stack line blamed on an inactive author,
+	// whom to ask instead.
+	Fallbacks map[string]*Fallback
+
+	// Good is the known-good revision, if any.
+	Good string
+
+	// WorkingTree tells whether the working tree was blamed rather than
+	// the revision.
+	WorkingTree bool
+
+	// MessageOrigins are the commits that introduced or changed the panic
+	// message, found by searching the history for MessageNeedle.
+	MessageNeedle  string
+	MessageOrigins []*Commit
+
+	// Target is the revision looked for fixes in, and Fixes holds, for each
+	// stack line of the panicking goroutine, the commits up to it that may
+	// have fixed the crash.
+	Target string
+	Fixes  map[string]*Fix
+
+	// Dependencies holds, for each stack line in a dependency, its module
+	// and how the commit it is blamed on changed its version.
+	Dependencies map[string]*Dependency
+
+	// Lines holds, for each stack line, where it is at HEAD and in the
+	// working tree.
+	Lines map[string]*MappedLine
+
+	// Bisection is the result of bisecting the crash, if requested.
+	Bisection *Bisection
+
+	// Repositories holds, for each stack line from another repository than
+	// the one of the Source, that repository.
+	Repositories map[string]*Repository
+
+	source       *Source
+	authors      *mailmap
+	repositories *repositories
+	locations    map[string]location
 }
 
 type Source struct {
+	// Repository is the local clone, or a clone URL to mirror in Cache,
+	// the user's cache directory by default.
 	Repository string `yaml:"repository,omitempty"`
 	Revision   string `yaml:"revision,omitempty"`
+	Cache      string `yaml:"cache,omitempty"`
+
+	// Fetch tells how to fetch the revision if the repository lacks it:
+	// "deepen" deepens a shallow clone by Depth commits, 100 by default,
+	// until it has it, and "unshallow" fetches the whole history.
+	Fetch string `yaml:"fetch,omitempty"`
+	Depth int    `yaml:"depth,omitempty"`
+
+	// WorkingTree blames the working tree, staged and unstaged changes
+	// included, rather than the revision.
+	WorkingTree bool `yaml:"working_tree,omitempty"`
+
+	// Identities merges the alternative names and e-mails of the same
+	// person, on top of what the repository's .mailmap already does.
+	Identities []Alias `yaml:"identities,omitempty"`
+
+	// People is the directory of the authors; entries from PeopleFile, a
+	// YAML or CSV file, are appended to it.
+	People     Directory `yaml:"people,omitempty"`
+	PeopleFile string    `yaml:"people_file,omitempty"`
+
+	// Inactive configures when blamed authors are considered to have left.
+	Inactive Inactivity `yaml:"inactive,omitempty"`
+
+	// Good is a revision known not to crash, e.g. the previous release; the
+	// commits since then that touched the stack are alternative suspects.
+	Good string `yaml:"good,omitempty"`
+
+	// Bisect is a shell command reproducing the crash; if set, the commits
+	// between Good and Bad, the crash revision by default, are bisected
+	// with it.
+	// Each run of the command is stopped after BisectTimeout, e.g. "5m",
+	// 10 minutes by default. Bisecting is done by BisectDump rather than
+	// ParseDump, as it may take long.
+	Bisect        string `yaml:"bisect,omitempty"`
+	Bad           string `yaml:"bad,omitempty"`
+	BisectTimeout string `yaml:"bisect_timeout,omitempty"`
+
+	// Upstreams are the local clones of the repositories of dependencies,
+	// to blame their frames in; the ones the go command cloned in the
+	// module cache are found as well.
+	Upstreams []Upstream `yaml:"upstreams,omitempty"`
+
+	// Toolchain configures blaming the frames of the standard library on
+	// the commit that last changed the Go version, unless disabled.
+	Toolchain Toolchain `yaml:"toolchain,omitempty"`
+
+	// Target is the branch to look for fixes of the crash in, origin/main
+	// or HEAD by default.
+	Target string `yaml:"target,omitempty"`
+
+	// Repositories are the other repositories the code comes from, e.g. the
+	// siblings of a monorepo; the ones the go.work file and the replace
+	// directives point to are added.
+	Repositories []Repository `yaml:"repositories,omitempty"`
 }
 
 func (s *Source) ParseDump(message io.Reader) (Dump, error) {
@@ -32,29 +151,125 @@ func (s *Source) ParseDump(message io.Reader) (Dump, error) {
 		return Dump{}, err
 	}
 
+	people := s.People
+	if s.PeopleFile != "" {
+		more, err := loadDirectory(s.PeopleFile)
+		if err != nil {
+			return Dump{}, err
+		}
+		people = append(people, more...)
+	}
+
+	if isRemote(s.Repository) {
+		s.Repository, err = mirror(s.Repository, s.Cache, s.Revision)
+		if err != nil {
+			return Dump{}, err
+		}
+	}
+
+	inactive, err := newInactivity(s.Repository, s.Inactive)
+	if err != nil {
+		return Dump{}, err
+	}
+
 	if s.Revision == "" {
 		s.Revision = "HEAD"
 	}
-	cmd := exec.Command("git", "rev-parse", s.Revision)
-	cmd.Dir = s.Repository
-	cmd.Stderr = ioutil.Discard
-	revision, err := cmd.Output()
+	shallow := isShallow(s.Repository)
+	revision, ok := resolveCommit(s.Repository, s.Revision)
+	if !ok && s.Fetch != "" {
+		if err := fetchRevision(s.Repository, s.Revision, s.Fetch,
+			s.Depth); err != nil {
+			return Dump{}, fmt.Errorf("Failed to fetch %s:\n%s", s.Revision, err)
+		}
+		shallow = isShallow(s.Repository)
+		revision, ok = resolveCommit(s.Repository, s.Revision)
+	}
+	missing := ""
+	if !ok {
+		// The results are for HEAD then, as the UI and reports point out.
+		missing = s.Revision
+		if revision, ok = resolveCommit(s.Repository, "HEAD"); !ok {
+			revision = "HEAD"
+		}
+	}
+
+	var good []byte
+	if s.Good != "" {
+		good, err = git(s.Repository, "rev-parse", s.Good)
+		if err != nil {
+			return Dump{}, fmt.Errorf("Unknown good revision %s", s.Good)
+		}
+	}
+
+	target, err := resolveTarget(s.Repository, s.Target)
 	if err != nil {
-		revision = []byte("HEAD")
+		return Dump{}, err
 	}
 
 	dump := Dump{
-		Revision: strings.TrimSpace(string(revision)),
-		Buckets:  stack.SortBuckets(stack.Bucketize(routines, stack.AnyPointer)),
-		Commits:  DefaultCommits(),
-		Skipped:  skip.String(),
-		source:   s,
+		Revision:     revision,
+		WorkingTree:  s.WorkingTree,
+		Target:       target,
+		Fixes:        map[string]*Fix{},
+		Lines:        map[string]*MappedLine{},
+		Dependencies: map[string]*Dependency{},
+		Repositories: map[string]*Repository{},
+		Good:         strings.TrimSpace(string(good)),
+		Buckets:      stack.SortBuckets(stack.Bucketize(routines, stack.AnyPointer)),
+		Commits:      DefaultCommits(),
+		Skipped:      skip.String(),
+		Panic:        parsePanic(skip.String(), routines),
+		Owners:       map[string][]string{},
+		People:       people,
+		Fallbacks:    map[string]*Fallback{},
+		source:       s,
+	}
+	dump.MissingRevision, dump.Shallow = missing, shallow
+
+	if out, err := git(s.Repository, "show", "-s", "--format=%ct",
+		dump.Revision); err == nil {
+		date, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+		if err == nil {
+			dump.RevisionDate = time.Unix(date, 0)
+		}
+	}
+
+	dump.authors = newMailmap(s.Repository, dump.Revision, s.Identities)
+	dump.repositories = newRepositories(s, dump.Revision)
+	dump.locateAll()
+
+	// Each repository has its own code owners.
+	codeOwners := map[*Repository]*CodeOwners{}
+	for _, b := range dump.Buckets {
+		for i := range b.Stack.Calls {
+			c := &b.Stack.Calls[i]
+			repo, path := dump.locate(c)
+			if repo == nil {
+				continue
+			}
+			if _, ok := codeOwners[repo]; !ok {
+				codeOwners[repo] = loadCodeOwners(repo.Path, repo.Revision)
+			}
+			if owners := codeOwners[repo].Owners(path); owners != nil {
+				dump.Owners[c.SourcePath] = owners
+			}
+		}
+	}
+
+	deps := newDependencies(s.Repository, dump.Revision)
+	ups := newUpstreams(s.Upstreams)
+	goVersion, err := newToolchain(s.Repository, dump.Revision, s.Toolchain)
+	if err != nil {
+		return Dump{}, err
 	}
 
 	wg := sync.WaitGroup{}
 	type result struct {
 		source string
 		cm     Commit
+		dep    *Dependency
+		repo   *Repository
 	}
 	commits := make(chan result)
 
@@ -63,9 +278,52 @@ func (s *Source) ParseDump(message io.Reader) (Dump, error) {
 			wg.Add(1)
 			go func(c stack.Call) {
 				defer wg.Done()
-				cm, err := Blame(s.Repository, c.SourcePath, c.Line, dump.Revision)
+				if c.IsStdlib() && goVersion != nil {
+					cm, bumped, err := goVersion.Blame()
+					if err == nil {
+						dump.resolveIdentities(&cm)
+						commits <- result{c.FullSourceLine(), cm, &bumped, nil}
+					}
+					return
+				}
+				if dep := deps.dependency(&c); dep != nil {
+					cm, bumped, err := deps.Blame(dep)
+					bumped.Version, bumped.File = dep.Version, dep.File
+					if upstream, uerr := ups.Blame(&bumped, c.Line); uerr == nil {
+						cm, err = upstream, nil
+						cm.Repository = bumped.Module
+					}
+					if err == nil {
+						dump.resolveIdentities(&cm)
+						commits <- result{c.FullSourceLine(), cm, &bumped, nil}
+						return
+					}
+				}
+				file := c.SourcePath
+				repo, path := dump.locate(&c)
+				if repo != nil && repo.Name != "" {
+					revision := repo.Revision
+					if repo.workingTree {
+						revision = ""
+					}
+					cm, err := Blame(repo.Path, path, c.Line, revision)
+					if err == nil {
+						cm.Repository = repo.Name
+						dump.resolveIdentities(&cm)
+						commits <- result{c.FullSourceLine(), cm, nil, repo}
+					}
+					return
+				} else if repo != nil {
+					file = path
+				}
+				revision := dump.Revision
+				if s.WorkingTree {
+					revision = ""
+				}
+				cm, err := Blame(s.Repository, file, c.Line, revision)
 				if err == nil {
-					commits <- result{c.FullSourceLine(), cm}
+					dump.resolveIdentities(&cm)
+					commits <- result{c.FullSourceLine(), cm, nil, nil}
 				}
 			}(c)
 		}
@@ -78,7 +336,88 @@ func (s *Source) ParseDump(message io.Reader) (Dump, error) {
 
 	for r := range commits {
 		dump.Commits.Add(r.source, r.cm)
+		if r.dep != nil {
+			dump.Dependencies[r.source] = r.dep
+			if r.dep.Upstream != "" {
+				dump.repositories.addUpstream(r.dep)
+			}
+		}
+		if r.repo != nil {
+			dump.Repositories[r.source] = r.repo
+		}
 	}
 	dump.Commits.SortByDate()
+
+	if inactive.enabled() {
+		dump.findFallbacks(inactive)
+	}
+	dump.findMessageOrigins()
+	dump.findFixes()
+	dump.mapLines()
+
 	return dump, nil
 }
+
+// BisectDump bisects the crash of the dump with the configured command, if
+// any.
+func (s *Source) BisectDump(d *Dump) *Bisection {
+	if s.Bisect == "" {
+		return nil
+	}
+	bad := s.Bad
+	if bad == "" {
+		bad = d.Revision
+	}
+	var timeout time.Duration
+	if s.BisectTimeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(s.BisectTimeout); err != nil {
+			return &Bisection{Good: d.Good, Bad: bad, Command: s.Bisect,
+				Error: fmt.Sprintf("Invalid bisect timeout %q", s.BisectTimeout)}
+		}
+	}
+	if d.Good == "" {
+		return &Bisection{Bad: bad, Command: s.Bisect,
+			Error: "Bisecting needs a good revision"}
+	}
+	return d.Bisect(d.Good, bad, s.Bisect, timeout)
+}
+
+// findFallbacks suggests other recipients for the stack lines blamed on
+// inactive authors.
+func (d *Dump) findFallbacks(in *inactivity) {
+	type key struct {
+		email string
+		repo  *Repository
+		file  string
+	}
+	found := map[key]*Fallback{}
+	for _, b := range d.Buckets {
+		for i := range b.Stack.Calls {
+			c := &b.Stack.Calls[i]
+			cm := d.Commits.BySource[c.FullSourceLine()]
+			if cm == nil || !in.Inactive(d.authors.Emails(cm.Identity())...) {
+				continue
+			}
+			repo, path := d.locate(c)
+			if repo == nil {
+				continue
+			}
+			k := key{cm.Email, repo, path}
+			if _, ok := found[k]; !ok {
+				found[k] = in.Fallback(repo, cm.Identity(), path,
+					d.Owners[c.SourcePath])
+			}
+			d.Fallbacks[c.FullSourceLine()] = found[k]
+		}
+	}
+}
+
+// resolveIdentities replaces the people named in the commit with their
+// canonical identities.
+func (d *Dump) resolveIdentities(cm *Commit) {
+	cm.SetIdentity(d.authors.Resolve(cm.Identity()))
+	d.authors.ResolveAll(cm.CoAuthors)
+	d.authors.ResolveAll(cm.Reviewers)
+	d.authors.ResolveAll(cm.SignedOffBy)
+}
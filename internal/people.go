@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Person is an entry of the people directory. Email is either an exact
+// e-mail address or a pattern, e.g. "*@payments.example.com", in which case
+// the entry provides defaults for everyone matching it.
+type Person struct {
+	Email   string `yaml:"email"`
+	Name    string `yaml:"name,omitempty"`
+	Team    string `yaml:"team,omitempty"`
+	Slack   string `yaml:"slack,omitempty"`
+	Matrix  string `yaml:"matrix,omitempty"`
+	Manager string `yaml:"manager,omitempty"`
+}
+
+// merge fills the empty fields of p with the ones of other.
+func (p *Person) merge(other *Person) {
+	fields := []struct{ dst, src *string }{
+		{&p.Name, &other.Name},
+		{&p.Team, &other.Team},
+		{&p.Slack, &other.Slack},
+		{&p.Matrix, &other.Matrix},
+		{&p.Manager, &other.Manager},
+	}
+	for _, f := range fields {
+		if *f.dst == "" {
+			*f.dst = *f.src
+		}
+	}
+}
+
+// Directory maps e-mails to people.
+type Directory []Person
+
+// Lookup returns what the directory knows about the owner of the e-mail: the
+// exact entry, completed with the first matching pattern. It returns nil if
+// nothing matches.
+func (d Directory) Lookup(email string) *Person {
+	if email == "" {
+		return nil
+	}
+	email = strings.ToLower(email)
+
+	var exact, pattern *Person
+	for i := range d {
+		p := &d[i]
+		entry := strings.ToLower(p.Email)
+		if entry == email {
+			if exact == nil {
+				exact = p
+			}
+		} else if ok, _ := path.Match(entry, email); ok && pattern == nil {
+			pattern = p
+		}
+	}
+	if exact == nil && pattern == nil {
+		return nil
+	}
+
+	found := &Person{Email: email}
+	if exact != nil {
+		*found = *exact
+	}
+	if pattern != nil {
+		found.merge(pattern)
+	}
+	return found
+}
+
+// loadDirectory reads the people directory from a YAML or CSV file. The CSV
+// file starts with a header naming the columns, e.g. "email,name,slack".
+func loadDirectory(file string) (Directory, error) {
+	if filepath.Ext(file) != ".csv" {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var d Directory
+		return d, yaml.Unmarshal(b, &d)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("No header in %s", file)
+	}
+
+	columns := make([]string, len(records[0]))
+	hasEmail := false
+	for col, name := range records[0] {
+		columns[col] = strings.ToLower(strings.TrimSpace(name))
+		switch columns[col] {
+		case "email":
+			hasEmail = true
+		case "name", "team", "slack", "matrix", "manager":
+		default:
+			return nil, fmt.Errorf("Unknown column %q in %s", name, file)
+		}
+	}
+	if !hasEmail {
+		return nil, fmt.Errorf("No email column in %s", file)
+	}
+
+	d := make(Directory, len(records)-1)
+	for i, record := range records[1:] {
+		p := &d[i]
+		fields := map[string]*string{
+			"email":   &p.Email,
+			"name":    &p.Name,
+			"team":    &p.Team,
+			"slack":   &p.Slack,
+			"matrix":  &p.Matrix,
+			"manager": &p.Manager,
+		}
+		for col, name := range columns {
+			*fields[name] = strings.TrimSpace(record[col])
+		}
+	}
+	return d, nil
+}
+
+// Person returns the directory entry of the author of the commit, with the
+// name taken from the commit if the directory does not provide one.
+func (d *Dump) Person(c *Commit) *Person {
+	if c == nil {
+		return nil
+	}
+	p := d.People.Lookup(c.Email)
+	if p != nil && p.Name == "" {
+		p.Name = c.Author
+	}
+	return p
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+)
+
+// minNeedle is the length under which a piece of the panic message is too
+// common to tell where it came from.
+const minNeedle = 8
+
+var (
+	// reVariable matches the parts of a message likely to be formatted
+	// values rather than literal text: numbers, addresses and quoted
+	// strings.
+	reVariable = regexp.MustCompile(`0x[0-9a-fA-F]+|-?[0-9]+(\.[0-9]+)?|"[^"]*"|'[^']*'`)
+	// reRuntime matches the messages of the runtime, which do not come from
+	// the code of the repository.
+	reRuntime = regexp.MustCompile(`^(runtime error|assignment to entry in nil map|all goroutines are asleep)`)
+)
+
+// messagePattern returns a regular expression matching the literal text of
+// the message with the formatted values replaced by wildcards, or "" if no
+// literal text is left.
+func messagePattern(message string) string {
+	var parts []string
+	literal := 0
+	for i, text := range reVariable.Split(message, -1) {
+		if i > 0 {
+			parts = append(parts, ".*")
+		}
+		literal += len(strings.TrimSpace(text))
+		parts = append(parts, regexp.QuoteMeta(text))
+	}
+	if literal < minNeedle {
+		return ""
+	}
+	return strings.Join(parts, "")
+}
+
+// findMessageOrigins searches the history for the commits that introduced or
+// changed the panic message. The message is looked for as is, then with its
+// formatted values replaced by wildcards, then by its longest prefix ending
+// before a colon.
+func (d *Dump) findMessageOrigins() {
+	message := strings.TrimSpace(d.Panic.Message())
+	if len(message) < minNeedle || reRuntime.MatchString(message) {
+		return
+	}
+
+	search := func(option, needle string) bool {
+		commits, err := d.commitsFromLog(d.repositories.all[0], "log", "-s",
+			"--format=%x00%H",
+			option+needle, d.Revision, "--", "*.go")
+		if err != nil || len(commits) == 0 {
+			return false
+		}
+		d.MessageNeedle = needle
+		d.MessageOrigins = commits
+		return true
+	}
+	if search("-S", message) {
+		return
+	}
+	if pattern := messagePattern(message); pattern != "" &&
+		pattern != regexp.QuoteMeta(message) && search("-G", pattern) {
+		return
+	}
+	if i := strings.LastIndex(message, ": "); i >= minNeedle {
+		search("-S", message[:i])
+	}
+}
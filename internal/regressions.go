@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/maruel/panicparse/stack"
+)
+
+// weightFunction is how much more a commit that changed the function of a
+// frame counts than one that only changed its file.
+const weightFunction = 2
+
+// Regressions ranks the commits between the known-good revision and the
+// crash revision that changed the files of the stack frames, the ones that
+// changed the functions of most frames first. It returns nil if no good
+// revision was given. The good revision is one of the main repository, so
+// only its frames are looked at.
+func (d *Dump) Regressions() ([]Suspect, error) {
+	if d.Good == "" {
+		return nil, nil
+	}
+	main := d.repositories.all[0]
+	span := d.Good + ".." + d.Revision
+
+	// The frames by file, each distinct stack line once.
+	var paths []string
+	frames := map[string][]*stack.Call{}
+	seen := map[string]bool{}
+	for i := range d.Buckets {
+		calls := d.Buckets[i].Stack.Calls
+		for j := range calls {
+			c := &calls[j]
+			path := d.mainPath(c)
+			if path == "" || seen[c.FullSourceLine()] {
+				continue
+			}
+			seen[c.FullSourceLine()] = true
+			if _, ok := frames[path]; !ok {
+				paths = append(paths, path)
+			}
+			frames[path] = append(frames[path], c)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	files, order, err := d.changedFiles(span, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	// The commits that changed the function of each frame.
+	functions := map[string]map[string]bool{}
+	for _, path := range paths {
+		for _, c := range frames[path] {
+			if !isOwnCode(c) {
+				continue
+			}
+			start, end, err := functionRange(main, c, path)
+			if err != nil {
+				continue
+			}
+			out, err := git(main.Path, "log", "-s", "--format=%x00%H",
+				fmt.Sprintf("-L%d,%d:%s", start, end, path), span)
+			if err != nil {
+				continue
+			}
+			ids := map[string]bool{}
+			for _, line := range strings.Split(string(out), "\n") {
+				if strings.HasPrefix(line, "\x00") {
+					ids[line[1:]] = true
+				}
+			}
+			functions[c.FullSourceLine()] = ids
+		}
+	}
+
+	var regressions []Suspect
+	for _, id := range order {
+		var changedFunctions, changedFiles int
+		var touched []string
+		for _, path := range files[id] {
+			for _, c := range frames[path] {
+				source := c.FullSourceLine()
+				touched = append(touched, source)
+				if functions[source][id] {
+					changedFunctions++
+				} else {
+					changedFiles++
+				}
+			}
+		}
+		if len(touched) == 0 {
+			continue
+		}
+		cm, err := d.Commit(main, id)
+		if err != nil {
+			return nil, err
+		}
+		s := Suspect{
+			Commit: cm,
+			Signals: []Signal{
+				{"functions", float64(weightFunction * changedFunctions)},
+				{"files", float64(changedFiles)},
+			},
+			Frames: touched,
+		}
+		for _, signal := range s.Signals {
+			s.Score += signal.Score
+		}
+		regressions = append(regressions, s)
+	}
+	sort.Stable(byScore(regressions))
+	return regressions, nil
+}
+
+// changedFiles returns, for the commits in the span that changed any of the
+// paths, the paths they changed, with the IDs of the commits newest first.
+func (d *Dump) changedFiles(span string, paths []string) (map[string][]string,
+	[]string, error) {
+	args := append([]string{"log", "--format=%x00%H", "--name-only", span,
+		"--"}, paths...)
+	out, err := git(d.source.Repository, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	files := map[string][]string{}
+	var order []string
+	var id string
+	for _, line := range strings.Split(string(out), "\n") {
+		switch {
+		case strings.HasPrefix(line, "\x00"):
+			id = line[1:]
+			order = append(order, id)
+		case line != "" && id != "":
+			files[id] = append(files[id], line)
+		}
+	}
+	return files, order, nil
+}
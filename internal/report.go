@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"text/template"
+)
+
+// ReportFrame is a stack frame in the report.
+type ReportFrame struct {
+	Function   string
+	File       string
+	Line       int
+	CommitID   string      `json:",omitempty"`
+	Owners     []string    `json:",omitempty"`
+	Fallback   *Fallback   `json:",omitempty"`
+	Fix        *Fix        `json:",omitempty"`
+	Dependency *Dependency `json:",omitempty"`
+	// Repository names the repository of the frame, if not the main one.
+	Repository string `json:",omitempty"`
+	// Now is where the line is at HEAD and in the working tree.
+	Now *MappedLine `json:",omitempty"`
+}
+
+// ReportGoroutine is a bucket of goroutines with the same stack.
+type ReportGoroutine struct {
+	IDs       []int
+	State     string
+	Panicking bool
+	Frames    []ReportFrame
+}
+
+// Report is the analysis of the dump in a form suitable for other tools.
+type Report struct {
+	Revision   string
+	Panic      Panic
+	Goroutines []ReportGoroutine
+	Commits    []*Commit
+	Suspects   []Suspect
+	// MessageNeedle is what the history was searched for to find the
+	// commits that introduced the panic message.
+	MessageNeedle string `json:",omitempty"`
+	// Functions are the histories of the functions of the panicking
+	// goroutine.
+	Functions []*FunctionHistory
+	// Regressions are the commits since the Good revision that touched the
+	// stack, if one was given.
+	Good        string     `json:",omitempty"`
+	Regressions []Suspect  `json:",omitempty"`
+	Bisection   *Bisection `json:",omitempty"`
+	// Fixes are the commits up to the Target that may have fixed the
+	// crash, oldest first.
+	Target string    `json:",omitempty"`
+	Fixes  []*Commit `json:",omitempty"`
+	// Warning tells why the results may be wrong, e.g. the crash revision
+	// is missing from the repository and Revision is HEAD instead.
+	MissingRevision string `json:",omitempty"`
+	Shallow         bool   `json:",omitempty"`
+	Warning         string `json:",omitempty"`
+}
+
+// Report gathers the analysis of the dump.
+func (d *Dump) Report() Report {
+	r := Report{
+		Revision:      d.Revision,
+		Panic:         d.Panic,
+		Commits:       d.Commits.All,
+		Suspects:      d.Suspects(),
+		MessageNeedle: d.MessageNeedle,
+		Good:          d.Good,
+		Bisection:     d.Bisection,
+	}
+	r.MissingRevision, r.Shallow = d.MissingRevision, d.Shallow
+	r.Warning = d.RevisionWarning()
+	if d.Target != d.Revision {
+		r.Target = d.Target
+	}
+	if regressions, err := d.Regressions(); err == nil {
+		r.Regressions = regressions
+	}
+
+	seen := map[string]bool{}
+	for _, b := range d.Buckets {
+		g := ReportGoroutine{State: b.State, Panicking: b.First()}
+		for _, routine := range b.Routines {
+			g.IDs = append(g.IDs, routine.ID)
+		}
+		for i := range b.Stack.Calls {
+			c := &b.Stack.Calls[i]
+			frame := ReportFrame{
+				Function:   c.Func.PkgDotName(),
+				File:       c.SourcePath,
+				Line:       c.Line,
+				Owners:     d.Owners[c.SourcePath],
+				Fallback:   d.Fallbacks[c.FullSourceLine()],
+				Fix:        d.Fixes[c.FullSourceLine()],
+				Now:        d.Lines[c.FullSourceLine()],
+				Dependency: d.Dependencies[c.FullSourceLine()],
+			}
+			if frame.Fix != nil {
+				r.addFixes(frame.Fix.Commits())
+			}
+			if cm := d.Commits.BySource[c.FullSourceLine()]; cm != nil {
+				frame.CommitID = cm.ID
+			}
+			if repo := d.Repositories[c.FullSourceLine()]; repo != nil {
+				frame.Repository = repo.Name
+			}
+			g.Frames = append(g.Frames, frame)
+
+			if !b.First() || c.IsStdlib() || seen[frame.Function] {
+				continue
+			}
+			seen[frame.Function] = true
+			if h, err := d.FunctionHistory(c); err == nil {
+				r.Functions = append(r.Functions, h)
+			}
+		}
+		r.Goroutines = append(r.Goroutines, g)
+	}
+	return r
+}
+
+func (r *Report) addFixes(commits []*Commit) {
+	for _, cm := range commits {
+		found := false
+		for _, fix := range r.Fixes {
+			found = found || fix == cm
+		}
+		if !found {
+			r.Fixes = append(r.Fixes, cm)
+		}
+	}
+	sort.SliceStable(r.Fixes, func(i, j int) bool {
+		return r.Fixes[i].Date.Before(r.Fixes[j].Date)
+	})
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteMarkdown writes the report as a Markdown document, e.g. for an issue.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	return report.Execute(w, r)
+}
+
+var report = template.Must(template.New("report").Funcs(messageFuncs).
+	Parse(_escFSMustString(false, "/templates/report.template")))
@@ -0,0 +1,376 @@
+package internal
+
+import (
+	"io/ioutil"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/maruel/panicparse/stack"
+)
+
+// Repository is another repository the code of the dump comes from, e.g. a
+// sibling of the main one.
+type Repository struct {
+	// Name identifies the repository in the commit panel and selects its
+	// URL templates; it defaults to the base name of Path.
+	Name string `yaml:"name,omitempty"`
+	// Path is the local clone.
+	Path string `yaml:"path"`
+	// Revision is the revision to blame, HEAD by default.
+	Revision string `yaml:"revision,omitempty"`
+	// Modules maps the paths of the Go modules in the repository to their
+	// directory in it, "" for its root.
+	Modules map[string]string `yaml:"modules,omitempty"`
+	// Prefixes are the paths the repository was built at, e.g. on a CI
+	// machine, which the source paths of the frames start with.
+	Prefixes []string `yaml:"prefixes,omitempty"`
+
+	// files are the files of a bare repository at the revision.
+	files []string
+	// workingTree tells to blame the working tree rather than Revision.
+	workingTree bool
+}
+
+// importPath returns the import path of the package of the function. Like
+// Function.PkgName, it cuts the raw name at the first dot after the last
+// slash: the dots in the last element of the import path, as in
+// gopkg.in/yaml.v2, are escaped in stack traces.
+func importPath(f stack.Function) string {
+	dir, base := path.Split(f.Raw)
+	pkg, _ := url.QueryUnescape(dir + strings.SplitN(base, ".", 2)[0])
+	return pkg
+}
+
+// relative returns the path of the file in the repository, or an empty
+// string if it is outside of it.
+func (r *Repository) relative(file string) string {
+	if rel := relativePath(r.Path, file); rel != "" {
+		return rel
+	}
+	for _, prefix := range r.Prefixes {
+		if rel := relativePath(prefix, file); rel != "" {
+			return rel
+		}
+	}
+	return ""
+}
+
+// repositories maps the frames to the repository they come from. The first
+// one is the repository of the Source.
+type repositories struct {
+	all []*Repository
+	// submodules are the ones of the main repository, by their path in it.
+	submodules map[string]*Repository
+	// upstreams are the clones the frames of dependencies were blamed in,
+	// named by their module. No frame is located in them.
+	upstreams []*Repository
+}
+
+// newRepositories gathers the configured repositories and the ones the
+// go.work file and the replace directives of the main repository point to.
+// The main repository is the first one, without a name.
+func newRepositories(s *Source, revision string) *repositories {
+	main := &Repository{Path: s.Repository, Revision: revision,
+		Modules: map[string]string{}}
+	repos := &repositories{all: []*Repository{main},
+		submodules: map[string]*Repository{}}
+	for i := range s.Repositories {
+		r := s.Repositories[i]
+		if sameDir(r.Path, main.Path) {
+			// Only the prefixes and modules of the main repository can be
+			// configured.
+			main.Prefixes = append(main.Prefixes, r.Prefixes...)
+			for module, dir := range r.Modules {
+				main.Modules[module] = dir
+			}
+			continue
+		}
+		if r.Name == "" {
+			r.Name = filepath.Base(r.Path)
+		}
+		if r.Revision == "" {
+			r.Revision = "HEAD"
+		}
+		if out, err := git(r.Path, "rev-parse", r.Revision); err == nil {
+			r.Revision = strings.TrimSpace(string(out))
+		}
+		repos.all = append(repos.all, &r)
+	}
+
+	// A bare repository, e.g. a mirror, has no working tree the frames
+	// could be in, so they are matched by the end of their path instead.
+	out, err := git(main.Path, "rev-parse", "--is-bare-repository")
+	if err == nil && strings.TrimSpace(string(out)) == "true" {
+		out, err := git(main.Path, "ls-tree", "-r", "--name-only", revision)
+		if err == nil {
+			main.files = strings.Split(strings.TrimSpace(string(out)), "\n")
+		}
+	}
+
+	// The modules in submodules belong to them rather than the main
+	// repository.
+	repos.addSubmodules(main, revision, s.WorkingTree)
+
+	var dirs []string
+	if out, err := git(s.Repository, "show", revision+":go.work"); err == nil {
+		dirs = append(dirs, parseDirectives(string(out), "use")...)
+		dirs = append(dirs, parseDirectives(string(out), "replace")...)
+	}
+	if out, err := git(s.Repository, "show", revision+":go.mod"); err == nil {
+		if module := moduleName(out); module != "" {
+			main.Modules[module] = ""
+		}
+		dirs = append(dirs, parseDirectives(string(out), "replace")...)
+	}
+	for _, dir := range dirs {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(s.Repository, dir)
+		}
+		repos.addModule(dir)
+	}
+	return repos
+}
+
+// parseDirectives returns the local directories of the use or replace
+// directives of a go.work or go.mod file.
+func parseDirectives(file, directive string) []string {
+	var dirs []string
+	block := false
+	for _, line := range strings.Split(file, "\n") {
+		line = strings.TrimSpace(strings.SplitN(line, "//", 2)[0])
+		switch {
+		case line == directive+" (":
+			block = true
+			continue
+		case block && line == ")":
+			block = false
+			continue
+		case strings.HasPrefix(line, directive+" "):
+			line = strings.TrimSpace(line[len(directive):])
+		case !block:
+			continue
+		}
+		if i := strings.Index(line, "=>"); i >= 0 {
+			line = strings.TrimSpace(line[i+2:])
+		} else if directive == "replace" {
+			continue
+		}
+		dir := strings.Trim(strings.Fields(line + " ")[0], `"`)
+		if strings.HasPrefix(dir, ".") || filepath.IsAbs(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// addModule maps the module in the directory to the repository containing
+// it, adding the repository if it is not known yet.
+func (repos *repositories) addModule(dir string) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return
+	}
+	module := moduleName(b)
+	out, err := git(dir, "rev-parse", "--show-toplevel")
+	if module == "" || err != nil {
+		return
+	}
+	top := strings.TrimSpace(string(out))
+
+	var repo *Repository
+	for _, r := range repos.all {
+		if sameDir(r.Path, top) {
+			repo = r
+			break
+		}
+	}
+	if repo == nil {
+		repo = &Repository{Name: filepath.Base(top), Path: top, Revision: "HEAD",
+			Modules: map[string]string{}}
+		if out, err := git(top, "rev-parse", "HEAD"); err == nil {
+			repo.Revision = strings.TrimSpace(string(out))
+		}
+		repos.all = append(repos.all, repo)
+	}
+	if repo.Modules == nil {
+		repo.Modules = map[string]string{}
+	}
+	if _, ok := repo.Modules[module]; !ok {
+		repo.Modules[module] = relativePath(top, dir)
+		if repo.Modules[module] == "." {
+			repo.Modules[module] = ""
+		}
+	}
+}
+
+// moduleName returns the path of the module declared by a go.mod file.
+func moduleName(gomod []byte) string {
+	for _, line := range strings.Split(string(gomod), "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+func sameDir(a, b string) bool {
+	a, errA := filepath.EvalSymlinks(a)
+	b, errB := filepath.EvalSymlinks(b)
+	return errA == nil && errB == nil && a == b
+}
+
+// locate returns the repository the frame comes from and the path of its
+// file in it, or nil if it is none of them.
+func (repos *repositories) locate(c *stack.Call) (*Repository, string) {
+	pkg := importPath(c.Func)
+	if filepath.IsAbs(c.SourcePath) {
+		for _, r := range repos.all {
+			if rel := r.relative(c.SourcePath); rel != "" {
+				return repos.inSubmodule(r, rel)
+			}
+		}
+	} else {
+		// Built with -trimpath, the path starts with the import path of the
+		// package instead, main packages included.
+		pkg = path.Dir(filepath.ToSlash(c.SourcePath))
+	}
+
+	var found *Repository
+	module := ""
+	for _, r := range repos.all {
+		for m := range r.Modules {
+			if (pkg == m || strings.HasPrefix(pkg, m+"/")) && len(m) > len(module) {
+				found, module = r, m
+			}
+		}
+	}
+	if found == nil {
+		return repos.bySuffix(c, pkg)
+	}
+	dir := path.Join(found.Modules[module],
+		strings.TrimPrefix(strings.TrimPrefix(pkg, module), "/"))
+	return repos.inSubmodule(found, path.Join(dir, filepath.Base(c.SourcePath)))
+}
+
+// bySuffix returns the main repository and the path of the file of the
+// frame in it, if it is bare and has the file, or else nil.
+func (repos *repositories) bySuffix(c *stack.Call, pkg string) (*Repository,
+	string) {
+	found := repos.all[0].suffix(c, pkg)
+	if found == "" {
+		return nil, ""
+	}
+	return repos.inSubmodule(repos.all[0], found)
+}
+
+// suffix returns the longest of the files of the bare repository the path of
+// the frame ends with. The frames of the standard library and of
+// dependencies are not looked for, and the directory of the file must end
+// the import path of the package, or can be any for a main package; the
+// files at the root are only found under the prefixes learnt by learnRoots.
+func (r *Repository) suffix(c *stack.Call, pkg string) string {
+	if !isOwnCode(c) {
+		return ""
+	}
+	file := filepath.ToSlash(c.SourcePath)
+	found := ""
+	for _, f := range r.files {
+		dir := path.Dir(f)
+		if strings.HasSuffix(file, "/"+f) && len(f) > len(found) &&
+			dir != "." && (pkg == "main" || strings.HasSuffix("/"+pkg, "/"+dir)) {
+			found = f
+		}
+	}
+	return found
+}
+
+// learnRoots adds to the prefixes of a bare main repository the directories
+// it was built in, as told by the frames found by the end of their path, for
+// the frames of the files at its root to be found as well.
+func (repos *repositories) learnRoots(calls []*stack.Call) {
+	main := repos.all[0]
+	for _, c := range calls {
+		f := main.suffix(c, importPath(c.Func))
+		if f == "" || !filepath.IsAbs(c.SourcePath) {
+			continue
+		}
+		root := strings.TrimSuffix(filepath.ToSlash(c.SourcePath), "/"+f)
+		known := false
+		for _, prefix := range main.Prefixes {
+			known = known || prefix == root
+		}
+		if !known {
+			main.Prefixes = append(main.Prefixes, root)
+		}
+	}
+}
+
+// find returns the repository with the given name, the main one for an
+// empty name.
+func (repos *repositories) find(name string) *Repository {
+	for _, r := range repos.all {
+		if r.Name == name {
+			return r
+		}
+	}
+	for _, r := range repos.upstreams {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// addUpstream records the clone the frame of the dependency was blamed in,
+// for its commits to be found by the name of the module.
+func (repos *repositories) addUpstream(dep *Dependency) {
+	if repos.find(dep.Module) == nil {
+		repos.upstreams = append(repos.upstreams, &Repository{Name: dep.Module,
+			Path: dep.Upstream, Revision: dep.Revision})
+	}
+}
+
+// location is the repository a stack line comes from, and the path of its
+// file in it.
+type location struct {
+	repo *Repository
+	path string
+}
+
+// locateAll finds the repository of each stack line once, after learning
+// where a bare main repository was built.
+func (d *Dump) locateAll() {
+	var calls []*stack.Call
+	for _, b := range d.Buckets {
+		for i := range b.Stack.Calls {
+			calls = append(calls, &b.Stack.Calls[i])
+		}
+	}
+	d.repositories.learnRoots(calls)
+
+	d.locations = map[string]location{}
+	for _, c := range calls {
+		if _, ok := d.locations[c.FullSourceLine()]; !ok {
+			repo, path := d.repositories.locate(c)
+			d.locations[c.FullSourceLine()] = location{repo, path}
+		}
+	}
+}
+
+// locate returns the repository the stack line comes from and the path of
+// its file in it, or nil if it is none of them.
+func (d *Dump) locate(c *stack.Call) (*Repository, string) {
+	l := d.locations[c.FullSourceLine()]
+	return l.repo, l.path
+}
+
+// mainPath returns the path of the file of the stack line in the repository
+// of the Source, or an empty string if it comes from another one.
+func (d *Dump) mainPath(c *stack.Call) string {
+	if repo, path := d.locate(c); repo != nil && repo == d.repositories.all[0] {
+		return path
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/maruel/panicparse/stack"
+)
+
+func TestImportPath(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"main.main", "main"},
+		{"net/http.(*conn).serve", "net/http"},
+		{"example.com/a/b.F.func1", "example.com/a/b"},
+		{"gopkg.in/yaml%2ev2.Unmarshal", "gopkg.in/yaml.v2"},
+		{"gopkg.in/yaml%2ev2.(*decoder).unmarshal", "gopkg.in/yaml.v2"},
+		{"example.com/foo%2ebar.(*T).M", "example.com/foo.bar"},
+		{"example.com/foo%2ebar.T.M", "example.com/foo.bar"},
+	}
+	for _, test := range tests {
+		if got := importPath(stack.Function{Raw: test.raw}); got != test.want {
+			t.Errorf("importPath(%q) = %q, want %q", test.raw, got, test.want)
+		}
+	}
+}
+
+func TestSuffix(t *testing.T) {
+	r := &Repository{files: []string{"proc.go", "lib/lib.go", "cmd/x/main.go"}}
+	tests := []struct {
+		raw, file string
+		want      string
+	}{
+		{"runtime.gopark", "/usr/local/go/src/runtime/proc.go", ""},
+		{"github.com/x/demo/lib.F", "/ci/github.com/x/demo/lib/lib.go", "lib/lib.go"},
+		{"github.com/x/other/lib.F", "/ci/github.com/x/other/lib/lib.go", "lib/lib.go"},
+		{"github.com/x/demo/util.F", "/ci/github.com/x/demo/util/lib/lib.go", ""},
+		{"github.com/x/demo.X", "/ci/github.com/x/demo/proc.go", ""},
+		{"main.main", "/ci/github.com/x/demo/cmd/x/main.go", "cmd/x/main.go"},
+		{"github.com/y/dep.F", "/ci/vendor/github.com/y/dep/lib/lib.go", ""},
+	}
+	for _, test := range tests {
+		c := &stack.Call{SourcePath: test.file, Func: stack.Function{Raw: test.raw}}
+		if got := r.suffix(c, importPath(c.Func)); got != test.want {
+			t.Errorf("suffix(%s) = %q, want %q", test.file, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ways of fetching the crash revision when it is missing.
+const (
+	// FetchDeepen deepens a shallow clone a few commits at a time until
+	// the revision is found.
+	FetchDeepen = "deepen"
+	// FetchUnshallow fetches the whole history at once.
+	FetchUnshallow = "unshallow"
+)
+
+const defaultDepth = 100
+
+// isShallow tells whether the repository is a shallow clone.
+func isShallow(repo string) bool {
+	out, err := git(repo, "rev-parse", "--is-shallow-repository")
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// resolveCommit returns the ID of the commit of the revision, if the
+// repository has it.
+func resolveCommit(repo, revision string) (string, bool) {
+	out, err := git(repo, "rev-parse", "--verify", "--quiet",
+		revision+"^{commit}")
+	return strings.TrimSpace(string(out)), err == nil
+}
+
+// fetchRevision fetches from origin until the repository has the revision,
+// deepening or unshallowing it if it is a shallow clone.
+func fetchRevision(repo, revision, fetch string, depth int) error {
+	if fetch != FetchDeepen && fetch != FetchUnshallow {
+		return fmt.Errorf("Unknown fetch %q, expected %s or %s", fetch,
+			FetchDeepen, FetchUnshallow)
+	}
+	if depth <= 0 {
+		depth = defaultDepth
+	}
+	if !isShallow(repo) {
+		return gitVerbose(repo, "fetch", "--quiet", "origin")
+	}
+	if fetch == FetchUnshallow {
+		return gitVerbose(repo, "fetch", "--quiet", "--unshallow", "origin")
+	}
+
+	// Deepening stops making progress once the whole history is there.
+	count := ""
+	for isShallow(repo) {
+		if _, ok := resolveCommit(repo, revision); ok {
+			return nil
+		}
+		err := gitVerbose(repo, "fetch", "--quiet",
+			fmt.Sprintf("--deepen=%d", depth), "origin")
+		if err != nil {
+			return err
+		}
+		out, err := git(repo, "rev-list", "--count", "--all")
+		if err != nil || string(out) == count {
+			break
+		}
+		count = string(out)
+	}
+	return nil
+}
+
+// RevisionWarning tells, if needed, why the results may be wrong: the crash
+// revision is missing and another one was blamed instead, or the clone is
+// shallow.
+func (d *Dump) RevisionWarning() string {
+	switch {
+	case d.MissingRevision != "" && d.Shallow:
+		return fmt.Sprintf("Revision %s is not in the shallow clone, the "+
+			"results are for HEAD %.7s instead; fetch it with -fetch deepen "+
+			"or -fetch unshallow", d.MissingRevision, d.Revision)
+	case d.MissingRevision != "":
+		return fmt.Sprintf("Revision %s is not in the repository, the "+
+			"results are for HEAD %.7s instead; fetch it with git fetch",
+			d.MissingRevision, d.Revision)
+	case d.Shallow:
+		return "The clone is shallow, lines older than its history are " +
+			"blamed on its oldest commits; fetch it with git fetch --unshallow"
+	}
+	return ""
+}
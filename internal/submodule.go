@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// addSubmodules adds the submodules of the main repository, at the commits
+// recorded at the revision, or in their working tree if that is blamed.
+// They are named as in .gitmodules. The ones that are not checked out, e.g.
+// not initialized or in a bare repository, are left out: their frames stay
+// in the main repository, which has no file to blame for them.
+func (repos *repositories) addSubmodules(main *Repository, revision string,
+	workingTree bool) {
+	out, err := git(main.Path, "config", "--blob", revision+":.gitmodules",
+		"--get-regexp", `^submodule\..*\.path$`)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(fields[0], "submodule."),
+			".path")
+		dir := path.Clean(fields[1])
+
+		// The gitlink: "160000 commit <id>\t<path>".
+		out, err := git(main.Path, "ls-tree", revision, "--", dir)
+		entry := strings.Fields(string(out))
+		if err != nil || len(entry) < 3 || entry[1] != "commit" {
+			continue
+		}
+		sub := &Repository{Name: name, Path: filepath.Join(main.Path, dir),
+			Revision: entry[2], Modules: map[string]string{}}
+		out, err = git(sub.Path, "rev-parse", "--show-toplevel")
+		if err != nil || !sameDir(strings.TrimSpace(string(out)), sub.Path) {
+			continue
+		}
+		if workingTree {
+			// The working tree is blamed, but its files are linked to at
+			// the commit checked out.
+			sub.workingTree = true
+			if out, err := git(sub.Path, "rev-parse", "HEAD"); err == nil {
+				sub.Revision = strings.TrimSpace(string(out))
+			}
+		}
+		repos.all = append(repos.all, sub)
+		repos.submodules[dir] = sub
+	}
+}
+
+// inSubmodule returns the submodule the file of the main repository is in,
+// and its path in it, or else the repository and the path as given.
+func (repos *repositories) inSubmodule(r *Repository, rel string) (*Repository, string) {
+	if r != repos.all[0] {
+		return r, rel
+	}
+	rel = filepath.ToSlash(rel)
+	for dir, sub := range repos.submodules {
+		if strings.HasPrefix(rel, dir+"/") {
+			return sub, rel[len(dir)+1:]
+		}
+	}
+	return r, rel
+}
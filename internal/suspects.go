@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/maruel/panicparse/stack"
+)
+
+// Signal is a single component of the score of a suspect.
+type Signal struct {
+	Name  string
+	Score float64
+}
+
+// Suspect is a commit ranked by how likely it caused the crash.
+type Suspect struct {
+	Commit  *Commit
+	Score   float64
+	Signals []Signal
+	// Frames lists the stack lines blamed on the commit.
+	Frames []string
+}
+
+// Weights of the signals; each signal itself is between 0 and 1.
+const (
+	weightRecency   = 3
+	weightDepth     = 2
+	weightPanicking = 2
+	weightOwnCode   = 1
+	weightFrames    = 1
+	weightMessage   = 2
+
+	// recencyDays is the age of a commit, relative to the crash revision,
+	// at which its recency signal halves.
+	recencyDays = 30
+	// maxFrames is the number of frames after which touching more of them
+	// does not make the commit more suspicious.
+	maxFrames = 5
+)
+
+// isOwnCode returns whether the call is in the code of the repository, rather
+// than in the standard library or a vendored dependency.
+func isOwnCode(c *stack.Call) bool {
+	return !c.IsStdlib() &&
+		!strings.Contains(c.SourcePath, "/vendor/") &&
+		!strings.Contains(c.SourcePath, "/pkg/mod/")
+}
+
+// suspectFrame is a frame blamed on a suspect commit.
+type suspectFrame struct {
+	call      *stack.Call
+	depth     int
+	panicking bool
+	// bumped tells that the frame is in a dependency or the standard
+	// library, and was blamed on the commit that bumped it rather than on
+	// its line.
+	bumped bool
+}
+
+// Suspects ranks the blamed commits and the ones that introduced or changed
+// the panic message, most suspicious first.
+func (d *Dump) Suspects() []Suspect {
+	frames := map[*Commit][]suspectFrame{}
+	for i := range d.Buckets {
+		b := &d.Buckets[i]
+		for depth := range b.Stack.Calls {
+			c := &b.Stack.Calls[depth]
+			cm := d.Commits.BySource[c.FullSourceLine()]
+			if cm == nil {
+				continue
+			}
+			bumped := d.Dependencies[c.FullSourceLine()].bumped()
+			frames[cm] = append(frames[cm],
+				suspectFrame{c, depth, b.First(), bumped})
+		}
+	}
+
+	suspects := make([]Suspect, 0, len(frames))
+	for _, cm := range d.Commits.All {
+		if fs, ok := frames[cm]; ok {
+			suspects = append(suspects, d.suspect(cm, fs))
+		}
+	}
+	for _, cm := range d.MessageOrigins {
+		if _, ok := frames[cm]; !ok {
+			suspects = append(suspects, d.suspect(cm, nil))
+		}
+	}
+	sort.Stable(byScore(suspects))
+	return suspects
+}
+
+func (d *Dump) suspect(cm *Commit, frames []suspectFrame) Suspect {
+	var (
+		depth     = -1
+		panicking = 0.0
+		ownCode   = 0.0
+		touched   = 0.0
+		seen      = map[string]bool{}
+	)
+	s := Suspect{Commit: cm}
+	for _, f := range frames {
+		source := f.call.FullSourceLine()
+		if !seen[source] {
+			seen[source] = true
+			s.Frames = append(s.Frames, source)
+			if !f.bumped {
+				touched++
+			}
+		}
+		// A bump is blamed for every frame of its dependency, which says
+		// little about where the crash is.
+		if f.bumped {
+			continue
+		}
+		if depth < 0 || f.depth < depth {
+			depth = f.depth
+		}
+		if f.panicking {
+			panicking = 1
+		}
+		if isOwnCode(f.call) {
+			ownCode = 1
+		}
+	}
+
+	recency := 0.0
+	if !d.RevisionDate.IsZero() {
+		age := d.RevisionDate.Sub(cm.Date).Hours() / 24
+		if age < 0 {
+			age = 0
+		}
+		recency = 1 / (1 + age/recencyDays)
+	}
+	if touched > maxFrames {
+		touched = maxFrames
+	}
+
+	closeness := 0.0
+	if depth >= 0 {
+		closeness = 1 / float64(1+depth)
+	}
+	message := 0.0
+	for _, origin := range d.MessageOrigins {
+		if origin == cm {
+			message = 1
+		}
+	}
+
+	s.Signals = []Signal{
+		{"recency", weightRecency * recency},
+		{"depth", weightDepth * closeness},
+		{"panicking", weightPanicking * panicking},
+		{"own code", weightOwnCode * ownCode},
+		{"frames", weightFrames * touched / maxFrames},
+		{"message", weightMessage * message},
+	}
+	for _, signal := range s.Signals {
+		s.Score += signal.Score
+	}
+	return s
+}
+
+type byScore []Suspect
+
+func (s byScore) Len() int           { return len(s) }
+func (s byScore) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byScore) Less(i, j int) bool { return s[i].Score > s[j].Score }
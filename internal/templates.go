@@ -188,24 +188,60 @@ var _escData = map[string]*_escFile{
 
 	"/templates/commit.template": {
 		local:   "templates/commit.template",
-		size:    120,
-		modtime: 1461669753,
+		size:    1494,
+		modtime: 1792358133,
 		compressed: `
-H4sIAAAJbogA/3JJLEm1Uqiu1gvTAzH13PKLchNLFJSMDAzMdA0MdQ2MFAxNrQxMrAxMlWpruTxdoIo9
-XYA8x9KSjPwiqAiEAxR11c1NzMyBirqC2EBBLjDPN7W4ODE9FcgHBAAA///edHEveAAAAA==
+H4sIAAAAAAAC/7RUy27bOhDd6yvmCrhAAlzrOkHTAlo1fgFG7Tio3aBZMtLIZiORhkjFMYjZ9gP6if2S
+gg9bSlID3XQjzZCHc4ZnZmjMjusNJHfJUFYV10TG8AKSLyJzvsac6GaxguFiPp+uVuMR3I9X0UxmrIRG
+YZ2CMcl1ozeyJorGvYrx0q2NrUUUGYOlQqIR0+g2rJFMZF0xDfFlv/++17/o9S/h4irtv0v7VzFRNB05
+6HTkAtiEPuNWKq5lvSdqbYfqblk6kRP5jP4kOYs+/g5ijHCLIkeR2ZCt487OZd6UeBDqDmvFpSD6aEzr
+hIAh9zkTvECliQIgBb+xKB3r4e+FOmN5jvl5CAE/v/+wrDe4IwIuXAbHeEd1Z0xpyDZMrBG0hCcUuaz/
+72TbXtWVd6t0jawiGpSswhyasBAYbpneEAHTYMy25kIXEP+bfFCxLcQT95c8qd6EleUDyx6JIi8+cMEy
+zZ/wn0MxM77lKHRywyokgmv1CFwojSxPI2NaAFF05nymLOVBlSh6Q3qLtfLSW4aVu5z9upp596UGy9Kl
+6H4p2PKFlZewOdM1fybyf98BYek1ULA11kTBCNCweFKsF3MnpH41e8bUrqjJUHotFdFQ9ljb393YAWtr
+hDu02IN5Crrka4H5oigGeyLv9GRR9B72p04MG6VltaoZLx2DMckn3BM5/B0rm0632VrOUSm2xt9I4OYn
+WeyEixN5w4b5JrlotyD+D+Lu8WOf8We0B28Xy+V0MLuHyfTreASD+zQ6Zjvjws3qqWcnJnrb4/bd6T4d
+KXTvAWd+0HLQG4SSCzx/I9KkEZkOT8Ffoy4CyfkrZaNfAwCasvw61gUAAA==
 `,
 	},
 
 	"/templates/message.template": {
 		local:   "templates/message.template",
-		size:    360,
-		modtime: 1472117775,
+		size:    1092,
+		modtime: 1792354113,
 		compressed: `
-H4sIAAAJbogA/0yQsW7CMBCGdz/FKVKXqASDCkMGpKoItUOnSp06cIQjWNhxdD4LocjvXoeC6HKDz/93
-3907XaAsh6H6rt68c0aq1yhHzymV5bNSFx8ZmmsDhqFn08kBiqdqGQp4RD7WKYEJEMhSI7QHDIDQe6FO
-DFpg7wUajIHg4Dm3Dt5afzZdC8TsuVbb7VZliY1nh1J9CTYnYWwoP3xSCNjSOG4dXZ/S9bP6Gw17EjQ2
-1GqV/Zh6m0P/zDbR2jug+OmKsaygyJAbYJQdV3tE1ih0E4FirvVyomcTPYfZotYvtV5cw3dmSx0xjpSz
-kSO0ucRdlQ82dWc6Tc3OoqN8RPUbAAD//5EZW/9oAQAA
+H4sIAAAAAAAC/4xSz2/TMBS++694iooEVZZ1E9uhEhNoUwWHAWLSThz26r4mZo5fZDuUyvL/juw0a4CB
+uESW3/e+fD8cgtoCmg1U99UKtV6jfJyeqy8kVafI+OojthRjCDvlmykkxve0h/k8hD/A83kpxI4ALYEl
+lI0yNXDvwTPsuQd04BuCvImOTYwlrEli7/Llu943bA9cJewaBuXAMGg2NVlA6dV3goYsldDihjKb5LZV
+HkIgs0l6O6uM30Lxorp0RRJ+nQHVh5uBVMkm0TrSJD1tkiiEjj0Zr1CDZfYwSNqyBYQta8275ISsZbsU
+IZB2NMRwjOcz2ewoJ1zd6ZzU2xDG47AFaXiL3qof6WpyHDhzrGOYT5Ymsyc7Q1i/wCyamiaOr3kAuWT8
+OeZSiCy39vBSkzmuuldwFuOee3uI143sM1XCTMLyzQR8MD1TMZYHZvi9h5nMBYzj9EKmDTyXvxuNT3TA
+v/v9v2JHEX/vN40fHh5ECNWKbYu+uvMoH71FSSu2t+QcDlHf9G0Xo0hgMQiBDXlU2i3FFYRgqdMop62s
+eq1HguKrKdLnCooYR4IkPRk9rtygp4MQKM4Xi8uTxdnJ4hzOLpaL18vFRVoe3t199WlnKDUupIQQvrEy
+x1soYIBmg2JUUZMhi+m/+THXyjf9upLcnrqGO5cCr0/VWmNLe+7FzwEAK9BjHUQEAAA=
+`,
+	},
+
+	"/templates/report.template": {
+		local:   "templates/report.template",
+		size:    2490,
+		modtime: 1792358960,
+		compressed: `
+H4sIAAAAAAAC/9RVTY/bNhC961cMtC6wNtbKJocWMNACzW4dLJqkwbpALzmYlkbSJBIpkFS9C4n/veCH
+ZMte76mXHASQoxnO4+ObmSu4k0yVwDR03Z50CcknUop48Yj/kiLBjem6RhLXOcQ/Jb+oGBJj4Lr2XvOu
+w0rhC07H8cgzY6IhwT9McuKFMdFvsFiE3WKxgq5LnJtzH7y/ME6p3UrGC4QZ3cCshtWvkHxCpViBysVQ
+DjMyRpfIIZywWHTdzMcnf5K3rGDbdbPamO0hD+WQbKjgrDImWiyUWwbX8QeM6weei0m8v93VFXwQUrSa
+OKpogJscbM7nyAkmV6LM3enhXhkz3uYGxiQzOuSCawtGM43GzD1+d8vvjtUbaIbNgC3qYd3yVJPg0MNH
+kbKwvBN1TRp6+GvPUSroferkbyYL1MbAF6EU7apnyOkJM9g9Qz8c2i+Xy8l3EutNw9MHOtaS1ZaK3pE7
+gDJmC8FCFRqz6rrkI3H09jNpedQP98a4v98EcUjCDWKIrfkEyyCmNT0dpBRSnJ/vTr6uiON85H/APyK+
+FJYHj/lUH3DExaluNq1qMNXKvtMmFRKPX+aeabv/vdWlkNDDpt19w1RDf/4A9jtQPZxqyT7G+jaPIXFp
+An8vspuM9A4GCyRZC1kzDfG729ufl7dvl7fv4hM3DzQYJTYVS3E8NJQsxH0M8devvQueVmJw+YyYWSlE
+PlIBcS1F1qbECxAS0pLxwq51iV7wUIfD9ygRctFyp1eFTKbl4FmS0kI+Qy6k09tJtm0yBbOmp1C4p5Ug
++Dlzg9qO5B7ilxfU0nWv0dp1I5srOIA97qhHWB+xkKhsyw2IN8RThEKI7Dz7ByFCY/j/BDcB8MNobjJs
+3pPCUN+WwXEbRduQjVn3LexQ7xH5JWKB8RdIf88y/5I+2V1bNZK0MTlJpWHHMkj9I1wUy0U5hBlsdfCH
+lNYlZ1Rh5hyDZXDjAs4yunKZcpIctRKNjRpH5XdqGrTqCSt4ZfbfwL6ktIRUtFUGXGjYIWhUGrPkpBFO
+Cy900SDlYTvUb3TWkH2VnQ6V68lI2Wgm7WCylNhUc8tOhXyQijLG9xVUtnNM5jNz49nzf2lEs/A/+cxq
+9GN6xg5nz1++cPSIjZAaCuQomcYMnDwK0mW7S1JRv1GlaJSluHhDu4rV+Cza6L8BAE+MiMK6CQAA
 `,
 	},
 
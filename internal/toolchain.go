@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ToolchainFile is a file setting the Go version, and the pattern matching
+// the lines that do; its first group, if any, is the version.
+type ToolchainFile struct {
+	Path    string `yaml:"path"`
+	Pattern string `yaml:"pattern"`
+}
+
+// Toolchain configures how the frames of the standard library are blamed on
+// the commit that last changed the Go version.
+type Toolchain struct {
+	// Disabled leaves the frames of the standard library unblamed.
+	Disabled bool `yaml:"disabled,omitempty"`
+	// Files replace the default ones when given. Paths are git pathspecs.
+	Files []ToolchainFile `yaml:"files,omitempty"`
+}
+
+var defaultToolchainFiles = []ToolchainFile{
+	{"go.mod", `^(?:go|toolchain)\s+(\S+)`},
+	{".go-version", `^\s*(\S+)`},
+	{":(glob)**/Dockerfile*", `^FROM\s+(?:\S+/)?golang:(\S+)`},
+}
+
+// toolchain finds the commit that last changed the Go version.
+type toolchain struct {
+	repo, revision string
+	files          []ToolchainFile
+	patterns       []*regexp.Regexp
+
+	once   sync.Once
+	commit Commit
+	dep    Dependency
+	err    error
+}
+
+func newToolchain(repo, revision string, t Toolchain) (*toolchain, error) {
+	if t.Disabled {
+		return nil, nil
+	}
+	tc := &toolchain{repo: repo, revision: revision, files: t.Files}
+	if len(tc.files) == 0 {
+		tc.files = defaultToolchainFiles
+	}
+	for _, f := range tc.files {
+		re, err := regexp.Compile(f.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		tc.patterns = append(tc.patterns, re)
+	}
+	return tc, nil
+}
+
+// Blame returns the last commit that changed the Go version, with the
+// versions it changed.
+func (tc *toolchain) Blame() (Commit, Dependency, error) {
+	tc.once.Do(func() {
+		tc.dep.Module = "Go"
+		tc.err = fmt.Errorf("No commit changed the Go version")
+		paths := make([]string, len(tc.files))
+		for i, f := range tc.files {
+			paths[i] = f.Path
+		}
+		out, err := git(tc.repo, append([]string{"log", "--format=%H",
+			tc.revision, "--"}, paths...)...)
+		if err != nil {
+			return
+		}
+		for _, id := range strings.Fields(string(out)) {
+			if tc.versions(id) {
+				tc.commit, tc.err = LoadCommit(tc.repo, id)
+				return
+			}
+		}
+	})
+	return tc.commit, tc.dep, tc.err
+}
+
+// versions finds the old and new Go versions in the diff of the first file
+// the commit changed it in. It returns false if the commit did not change
+// the version.
+func (tc *toolchain) versions(id string) bool {
+	for i, f := range tc.files {
+		out, err := git(tc.repo, "show", "--format=", "-U0", "--no-color",
+			"--no-ext-diff", id, "--", f.Path)
+		if err != nil || len(out) == 0 {
+			continue
+		}
+		var older, newer, file string
+		for _, line := range strings.Split(string(out), "\n") {
+			if strings.HasPrefix(line, "+++ b/") && file == "" {
+				file = line[len("+++ b/"):]
+			}
+			if line == "" || strings.HasPrefix(line, "---") ||
+				strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "@@") {
+				continue
+			}
+			m := tc.patterns[i].FindStringSubmatch(line[1:])
+			if m == nil {
+				continue
+			}
+			version := strings.TrimSpace(m[0])
+			if len(m) > 1 {
+				version = m[1]
+			}
+			switch {
+			case line[0] == '-' && older == "":
+				older = version
+			case line[0] == '+' && newer == "":
+				newer = version
+			}
+		}
+		if older != newer {
+			tc.dep.Old, tc.dep.New, tc.dep.Manifest = older, newer, file
+			return true
+		}
+	}
+	return false
+}
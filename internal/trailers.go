@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Trailer is a single "Key: value" line from the trailer block at the end of
+// a commit message.
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+var reTrailer = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*):\s*(.*)$`)
+
+// parseTrailers returns the trailers of the commit message, i.e. the lines of
+// its last paragraph, provided that the paragraph consists of trailers only.
+// Continuation lines (starting with whitespace) are folded into the previous
+// trailer.
+func parseTrailers(message string) []Trailer {
+	message = strings.TrimSpace(message)
+	i := strings.LastIndex(message, "\n\n")
+	if i < 0 {
+		// The only paragraph is the subject.
+		return nil
+	}
+
+	var trailers []Trailer
+	for _, line := range strings.Split(message[i+2:], "\n") {
+		if line == "" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if len(trailers) == 0 {
+				return nil
+			}
+			last := &trailers[len(trailers)-1]
+			last.Value += " " + strings.TrimSpace(line)
+			continue
+		}
+		m := reTrailer.FindStringSubmatch(line)
+		if m == nil {
+			return nil
+		}
+		trailers = append(trailers, Trailer{Key: m[1], Value: strings.TrimSpace(m[2])})
+	}
+	return trailers
+}
+
+// Trailer returns the values of all the trailers with the given key. The key
+// is matched case-insensitively.
+func (c *Commit) Trailer(key string) []string {
+	var values []string
+	for _, t := range c.Trailers {
+		if strings.EqualFold(t.Key, key) {
+			values = append(values, t.Value)
+		}
+	}
+	return values
+}
+
+// CustomTrailers returns the trailers other than the ones naming co-authors,
+// reviewers and sign-offs.
+func (c *Commit) CustomTrailers() []Trailer {
+	var custom []Trailer
+	for _, t := range c.Trailers {
+		switch strings.ToLower(t.Key) {
+		case "co-authored-by", "reviewed-by", "signed-off-by":
+		default:
+			custom = append(custom, t)
+		}
+	}
+	return custom
+}
+
+func (c *Commit) identities(key string) []Identity {
+	var ids []Identity
+	for _, v := range c.Trailer(key) {
+		ids = append(ids, parseIdentity(v))
+	}
+	return ids
+}
+
+// setTrailers parses the trailers of the full message and fills in the
+// people mentioned in them.
+func (c *Commit) setTrailers() {
+	c.Trailers = parseTrailers(c.FullMessage)
+	c.CoAuthors = c.identities("Co-authored-by")
+	c.Reviewers = c.identities("Reviewed-by")
+	c.SignedOffBy = c.identities("Signed-off-by")
+}
@@ -2,10 +2,14 @@ package internal
 
 import (
 	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
 	"text/template"
 
 	"github.com/atotto/clipboard"
 	"github.com/gizak/termui"
+	"github.com/maruel/panicparse/stack"
 	"github.com/shopspring/iblameyou/widgets"
 	"github.com/toqueteos/webbrowser"
 )
@@ -15,6 +19,7 @@ type UI struct {
 	widgets struct {
 		commit     *termui.Par
 		stackTrace *widgets.ScrollableList
+		panel      *widgets.ScrollableList
 		messages   *widgets.MessageBox
 	}
 
@@ -25,6 +30,33 @@ type UI struct {
 
 	dump       *Dump
 	stackTrace []SourcePath
+
+	// view is shown in the panel below the stack trace; the panel is hidden
+	// when it is nil.
+	view *view
+	// panelFocused tells whether scrolling and the actions apply to the panel
+	// rather than to the stack trace.
+	panelFocused bool
+	height       int
+}
+
+// view is a list of commits shown in the panel, e.g. the ranked suspects.
+type view struct {
+	name    string
+	label   string
+	items   []string
+	commits []string
+	// related lists, for the items standing for several commits, all of
+	// them; commits holds the one shown in the commit panel.
+	related [][]string
+	// open returns the view to drill down to from the item, or nil.
+	open   func(item int) *view
+	parent *view
+	// alternate returns another version of the view, e.g. the diff of the
+	// whole commit instead of a single file.
+	alternate func() *view
+	// frame is the stack frame the view is about, if any.
+	frame *SourcePath
 }
 
 func (ui *UI) Init(f *Format) error {
@@ -42,8 +74,10 @@ func (ui *UI) Init(f *Format) error {
 	// Messages
 	ui.messages.status = &widgets.Message{Ticks: 5}
 	ui.messages.usage = &widgets.Message{
-		Content: "[m]essage | [f]ile | [c]ommit | [b]lame | j/k scroll | [q]uit",
-		Ticks:   -1}
+		Content: "[m]essage | [f]ile | [c]ommit | [b]lame | [s]uspects | since [g]ood | [B]isect | " +
+			"commit [l]ist | [a]uthors | [d]iff ([e]xpand) | line/function [h]/[H]istory | <tab> focus | j/k scroll | " +
+			"<enter>/<esc> drill down/up | [q]uit",
+		Ticks: -1}
 
 	// Widgets
 	ui.widgets.commit = termui.NewPar("")
@@ -53,6 +87,8 @@ func (ui *UI) Init(f *Format) error {
 	ui.widgets.stackTrace.BorderLabel = "Stacktrace"
 	ui.widgets.stackTrace.Items = []string{"Loading..."}
 
+	ui.widgets.panel = widgets.NewScrollableList()
+
 	ui.widgets.messages = widgets.NewMessageBox()
 	ui.widgets.messages.AddMessage(ui.messages.usage, widgets.Right)
 
@@ -62,31 +98,189 @@ func (ui *UI) Init(f *Format) error {
 	termui.Handle("/sys/kbd/q", func(termui.Event) {
 		termui.StopLoop()
 	})
+	termui.Handle(evtBisection, func(e termui.Event) {
+		ui.showBisection(e.Data.(*Bisection))
+	})
 
 	termui.Handle("/sys/kbd/k", func(termui.Event) {
-		ui.widgets.stackTrace.SelectPrevious()
+		ui.focused().SelectPrevious()
 		ui.updateCommit()
 		ui.refresh()
 	})
 	termui.Handle("/sys/kbd/j", func(termui.Event) {
-		ui.widgets.stackTrace.SelectNext()
+		ui.focused().SelectNext()
 		ui.updateCommit()
 		ui.refresh()
 	})
+	termui.Handle("/sys/kbd/<tab>", func(termui.Event) {
+		ui.setFocus(!ui.panelFocused)
+		ui.refresh()
+	})
+
+	termui.Handle("/sys/kbd/s", func(termui.Event) {
+		ui.toggleView("Suspects", func() view {
+			suspects := ui.dump.Suspects()
+			v := view{items: f.Suspects(suspects)}
+			if ui.dump.MessageNeedle != "" {
+				v.label = fmt.Sprintf("Suspects, message from %q",
+					ui.dump.MessageNeedle)
+			}
+			for _, s := range suspects {
+				v.commits = append(v.commits, s.Commit.ID)
+			}
+			return v
+		})
+	})
+
+	termui.Handle("/sys/kbd/g", func(termui.Event) {
+		if ui.dump != nil && ui.dump.Good == "" {
+			status := "Error: no good revision given!"
+			ui.showMessage(&status)
+			return
+		}
+		ui.toggleView("Regressions", func() view {
+			v := view{label: fmt.Sprintf("Since good %.7s", ui.dump.Good)}
+			regressions, err := ui.dump.Regressions()
+			if err != nil {
+				v.items = []string{"Error: " + err.Error()}
+				v.commits = []string{""}
+				return v
+			}
+			v.items = f.Suspects(regressions)
+			for _, s := range regressions {
+				v.commits = append(v.commits, s.Commit.ID)
+			}
+			return v
+		})
+	})
+
+	termui.Handle("/sys/kbd/B", func(termui.Event) {
+		if ui.dump != nil && ui.dump.Bisection == nil {
+			status := "Error: not bisected!"
+			ui.showMessage(&status)
+			return
+		}
+		ui.toggleView("Bisect", ui.bisectView)
+	})
+
+	termui.Handle("/sys/kbd/l", func(termui.Event) {
+		ui.toggleView("Commits", func() view {
+			v := view{items: f.Commits(ui.dump.Commits.All)}
+			for _, c := range ui.dump.Commits.All {
+				v.commits = append(v.commits, c.ID)
+			}
+			return v
+		})
+	})
+
+	termui.Handle("/sys/kbd/a", func(termui.Event) {
+		ui.toggleView("Authors", ui.authorsView)
+	})
+	termui.Handle("/sys/kbd/d", func(termui.Event) {
+		file, commit := ui.current()
+		if commit == nil && (ui.view == nil || ui.view.name != "Diff") {
+			status := "Error: no associated commit!"
+			ui.showMessage(&status)
+			return
+		}
+		path := file.File
+		if file.Path != "" {
+			// The file may come from a build elsewhere or another repository.
+			path = file.Path
+		} else if dep := file.Dependency; dep != nil && dep.Upstream != "" {
+			path = dep.Path
+		}
+		ui.toggleView("Diff", func() view {
+			return *ui.diffView(commit, path, false)
+		})
+	})
+	termui.Handle("/sys/kbd/h", func(termui.Event) {
+		file, _ := ui.current()
+		if file.File == "" && (ui.view == nil || ui.view.name != "History") {
+			status := "Error: no associated file!"
+			ui.showMessage(&status)
+			return
+		}
+		ui.toggleView("History", func() view {
+			v := view{
+				label: fmt.Sprintf("History of %s:%d",
+					filepath.Base(file.File), file.Line),
+				frame: &file,
+			}
+			commits, err := ui.dump.LineHistory(file.File, file.Line)
+			if err != nil {
+				v.items = []string{"Error: " + err.Error()}
+				v.commits = []string{""}
+				return v
+			}
+			v.items = f.Commits(commits)
+			for _, c := range commits {
+				v.commits = append(v.commits, c.ID)
+			}
+			return v
+		})
+	})
+	termui.Handle("/sys/kbd/H", func(termui.Event) {
+		if ui.dump == nil {
+			return
+		}
+		file, _ := ui.current()
+		call := ui.dump.Call(file.File, file.Line)
+		if call == nil && (ui.view == nil || ui.view.name != "Function") {
+			status := "Error: no associated function!"
+			ui.showMessage(&status)
+			return
+		}
+		ui.toggleView("Function", func() view {
+			return ui.functionView(call, file)
+		})
+	})
+	termui.Handle("/sys/kbd/e", func(termui.Event) {
+		if ui.view != nil && ui.view.alternate != nil {
+			ui.showView(ui.view.alternate())
+		}
+	})
+	termui.Handle("/sys/kbd/<enter>", func(termui.Event) {
+		if ui.panelFocused && ui.view.open != nil {
+			if v := ui.view.open(ui.widgets.panel.CurrentItem); v != nil {
+				v.parent = ui.view
+				ui.showView(v)
+			}
+		}
+	})
+	termui.Handle("/sys/kbd/<escape>", func(termui.Event) {
+		if ui.panelFocused && ui.view.parent != nil {
+			ui.showView(ui.view.parent)
+		}
+	})
 
 	termui.Handle("/sys/kbd/m", func(termui.Event) {
 		status := ""
 		defer ui.showMessage(&status)
 
-		file := ui.stackTrace[ui.widgets.stackTrace.CurrentItem]
-		if file.CommitID == "" {
+		file, commit := ui.current()
+		if commit == nil {
 			status = "Error: no associated commit!"
 			return
 		}
+		if commit.Uncommitted() {
+			status = "Error: not committed yet, nobody else to ask!"
+			return
+		}
+		var commits []*Commit
+		for _, c := range ui.dump.Commits.Get(ui.selectedCommits()) {
+			if !c.Uncommitted() {
+				commits = append(commits, c)
+			}
+		}
 
 		c := Candidate{
-			Commit: ui.dump.Commits.ByID[file.CommitID],
-			Dump:   ui.dump,
+			Commit:   commit,
+			Commits:  commits,
+			Dump:     ui.dump,
+			Owners:   file.Owners,
+			Person:   ui.dump.Person(commit),
+			Fallback: file.Fallback,
 		}
 
 		err = clipboard.WriteAll(f.Message(c))
@@ -98,33 +292,34 @@ func (ui *UI) Init(f *Format) error {
 	})
 	if f.templates.CommitURL != nil {
 		termui.Handle("/sys/kbd/c", func(termui.Event) {
-			file := ui.stackTrace[ui.widgets.stackTrace.CurrentItem]
+			file, _ := ui.current()
 			if file.CommitID != "" {
-				ui.open(f.templates.CommitURL, file)
+				ui.open(f.urls(&file).CommitURL, file)
 			}
 		})
 	}
 	if f.templates.FileURL != nil {
 		termui.Handle("/sys/kbd/f", func(termui.Event) {
-			file := ui.stackTrace[ui.widgets.stackTrace.CurrentItem]
+			file, _ := ui.current()
 			if file.File != "" {
-				ui.open(f.templates.FileURL, file)
+				ui.open(f.urls(&file).FileURL, file)
 			}
 		})
 	}
 	if f.templates.BlameURL != nil {
 		termui.Handle("/sys/kbd/b", func(termui.Event) {
-			file := ui.stackTrace[ui.widgets.stackTrace.CurrentItem]
+			file, _ := ui.current()
 			if file.File != "" {
-				ui.open(f.templates.BlameURL, file)
+				ui.open(f.urls(&file).BlameURL, file)
 			}
 		})
 	}
 
 	termui.Handle("/sys/wnd/resize", func(e termui.Event) {
 		wnd := e.Data.(termui.EvtWnd)
+		termui.Body.Width = wnd.Width
 		ui.SetHeight(wnd.Height)
-		termui.Body.Align()
+		ui.layout()
 		ui.refresh()
 	})
 	termui.Handle("/timer/1s", func(termui.Event) {
@@ -134,25 +329,288 @@ func (ui *UI) Init(f *Format) error {
 	})
 
 	// Layout
-	termui.Body.AddRows(
+	ui.setFocus(false)
+	ui.layout()
+	termui.Render(termui.Body)
+	termui.Render(termui.Body)
+
+	return nil
+}
+
+// layout arranges the widgets, showing the panel below the stack trace when
+// there is a view to show.
+func (ui *UI) layout() {
+	left := []termui.GridBufferer{ui.widgets.stackTrace}
+	if ui.view != nil {
+		left = append(left, ui.widgets.panel)
+	}
+	termui.Body.Rows = []*termui.Row{
 		termui.NewRow(
-			termui.NewCol(9, 0, ui.widgets.stackTrace),
+			termui.NewCol(9, 0, left...),
 			termui.NewCol(3, 0, ui.widgets.commit),
-		// termui.NewCol(4, 0, ui.widgets.commits),
 		),
 		termui.NewRow(termui.NewCol(12, 0, ui.widgets.messages)),
-	)
-
+	}
 	termui.Body.Align()
-	termui.Render(termui.Body)
-	termui.Render(termui.Body)
-
-	return nil
+	// The lists scroll according to their inner size, which is only updated
+	// when they are aligned.
+	ui.widgets.stackTrace.Align()
+	ui.widgets.panel.Align()
 }
 
 func (ui *UI) SetHeight(h int) {
+	ui.height = h
 	ui.widgets.commit.Height = h - 1
-	ui.widgets.stackTrace.Height = h - 1
+	if ui.view == nil {
+		ui.widgets.stackTrace.Height = h - 1
+		return
+	}
+	ui.widgets.stackTrace.Height = (h - 1) / 2
+	ui.widgets.panel.Height = h - 1 - ui.widgets.stackTrace.Height
+}
+
+// toggleView shows the view built by the function in the panel and focuses
+// it, or hides the panel if it already shows the view with that name.
+func (ui *UI) toggleView(name string, build func() view) {
+	if ui.dump == nil {
+		return
+	}
+	if ui.view != nil && ui.view.name == name {
+		ui.view = nil
+		ui.setFocus(false)
+		ui.SetHeight(ui.height)
+		ui.layout()
+		ui.refresh()
+		return
+	}
+	v := build()
+	v.name = name
+	if v.label == "" {
+		v.label = name
+	}
+	ui.showView(&v)
+}
+
+// showView shows the view in the panel and focuses it.
+func (ui *UI) showView(v *view) {
+	ui.view = v
+	ui.widgets.panel.BorderLabel = v.label
+	ui.widgets.panel.SetItems(v.items)
+	ui.SetHeight(ui.height)
+	ui.layout()
+	ui.setFocus(true)
+	ui.widgets.panel.Select(0)
+	ui.updateCommit()
+	ui.refresh()
+}
+
+// diffView shows the patch of the commit, limited to the file unless whole
+// is set.
+func (ui *UI) diffView(commit *Commit, file string, whole bool) *view {
+	v := &view{name: "Diff"}
+	what := fmt.Sprintf("%.7s", commit.ID)
+	if commit.Uncommitted() {
+		what = "uncommitted changes"
+	}
+	if whole || file == "" {
+		v.label = "Diff of " + what
+	} else {
+		v.label = fmt.Sprintf("Diff of %s in %s", what, filepath.Base(file))
+	}
+	v.alternate = func() *view {
+		return ui.diffView(commit, file, !whole)
+	}
+
+	patch, err := ui.dump.Diff(commit.ID, file, whole)
+	if err != nil {
+		patch = []string{"Error: " + err.Error()}
+	}
+	v.items = ui.format.Diff(patch)
+	v.commits = make([]string, len(v.items))
+	for i := range v.commits {
+		v.commits[i] = commit.ID
+	}
+	return v
+}
+
+// functionView shows who changed the function of the frame, and when.
+func (ui *UI) functionView(call *stack.Call, file SourcePath) view {
+	f := ui.format
+	v := view{label: "History of " + call.Func.PkgDotName(), frame: &file}
+	h, err := ui.dump.FunctionHistory(call)
+	if err != nil {
+		v.items = []string{"Error: " + err.Error()}
+		v.commits = []string{""}
+		return v
+	}
+
+	add := func(item, commit string, related []string) {
+		v.items = append(v.items, item)
+		v.commits = append(v.commits, commit)
+		v.related = append(v.related, related)
+	}
+	add(fmt.Sprintf("%s, %s:%d-%d, %d changes",
+		h.Function, h.File, h.Start, h.End, len(h.Commits)), "", nil)
+	for _, a := range h.Authors {
+		var ids []string
+		for _, c := range h.Commits {
+			if strings.EqualFold(c.Email, a.Author.Email) {
+				ids = append(ids, c.ID)
+			}
+		}
+		add(f.AuthorShare(&a), ids[0], ids)
+	}
+	add("", "", nil)
+	for i, item := range f.Commits(h.Commits) {
+		add(item, h.Commits[i].ID, nil)
+	}
+	return v
+}
+
+// bisectView shows the first bad commit found by bisecting, then the
+// revisions tested.
+func (ui *UI) bisectView() view {
+	f := ui.format
+	b := ui.dump.Bisection
+	v := view{label: fmt.Sprintf("Bisect %.7s..%.7s: %s", b.Good, b.Bad,
+		b.Command)}
+	if b.Culprit != nil {
+		v.items = append(v.items,
+			"First bad commit: "+f.Commits([]*Commit{b.Culprit})[0])
+		v.commits = append(v.commits, b.Culprit.ID)
+	} else if b.Error != "" {
+		v.items = append(v.items, colorf(f.Colors.Panic, "Error: %s", b.Error))
+		v.commits = append(v.commits, "")
+	} else {
+		v.items = append(v.items, "No first bad commit found")
+		v.commits = append(v.commits, "")
+	}
+	for _, step := range b.Steps {
+		verdict := colorf(f.Colors.CommitID, "good")
+		switch {
+		case step.Skipped:
+			verdict = colorf(f.Colors.CommitDate, "skip")
+		case step.Bad:
+			verdict = colorf(f.Colors.Panic, "bad ")
+		}
+		v.items = append(v.items, fmt.Sprintf("  %s %.7s", verdict,
+			step.Revision))
+		v.commits = append(v.commits, step.Revision)
+	}
+	return v
+}
+
+// authorsView aggregates the frames by author, grouped by team when the
+// people directory gives any.
+func (ui *UI) authorsView() view {
+	f := ui.format
+	authors := ui.dump.Authors()
+	v := view{}
+	rows := map[int]AuthorSummary{}
+	addAuthor := func(a AuthorSummary, indent string) {
+		rows[len(v.items)] = a
+		v.items = append(v.items, indent+f.AuthorSummary(&a))
+		v.commits = append(v.commits, a.Commits[0].ID)
+		v.related = append(v.related, a.CommitIDs())
+	}
+	teams := Teams(authors)
+	if teams == nil {
+		for _, a := range authors {
+			addAuthor(a, "")
+		}
+	}
+	for _, t := range teams {
+		var ids []string
+		for _, a := range t.Authors {
+			ids = append(ids, a.CommitIDs()...)
+		}
+		v.items = append(v.items, f.TeamSummary(&t))
+		v.commits = append(v.commits, "")
+		v.related = append(v.related, ids)
+		for _, a := range t.Authors {
+			addAuthor(a, "  ")
+		}
+	}
+
+	// Drill down to the frames of the author.
+	v.open = func(item int) *view {
+		a, ok := rows[item]
+		if !ok {
+			return nil
+		}
+		frames := &view{
+			label: "Frames of " + a.Author.Name,
+			items: f.Frames(ui.dump, a.Frames),
+		}
+		for _, c := range a.Frames {
+			frames.commits = append(frames.commits,
+				ui.dump.Commits.BySource[c.FullSourceLine()].ID)
+		}
+		return frames
+	}
+	return v
+}
+
+// setFocus moves the focus to the panel, if it is shown, or to the stack
+// trace.
+func (ui *UI) setFocus(panel bool) {
+	ui.panelFocused = panel && ui.view != nil
+	focused, other := ui.widgets.stackTrace, ui.widgets.panel
+	if ui.panelFocused {
+		focused, other = other, focused
+	}
+	focused.BorderFg = termui.ColorCyan
+	other.BorderFg = termui.ColorWhite
+	ui.updateCommit()
+}
+
+func (ui *UI) focused() *widgets.ScrollableList {
+	if ui.panelFocused {
+		return ui.widgets.panel
+	}
+	return ui.widgets.stackTrace
+}
+
+// current returns the selected stack frame and its commit. When the panel is
+// focused, the frame is the first one blamed on the selected commit.
+func (ui *UI) current() (SourcePath, *Commit) {
+	if ui.dump == nil {
+		return SourcePath{}, nil
+	}
+	if !ui.panelFocused {
+		i := ui.widgets.stackTrace.CurrentItem
+		if i < 0 || i >= len(ui.stackTrace) {
+			return SourcePath{}, nil
+		}
+		file := ui.stackTrace[i]
+		return file, ui.dump.Commits.ByID[file.CommitID]
+	}
+
+	i := ui.widgets.panel.CurrentItem
+	if i < 0 || i >= len(ui.view.commits) {
+		return SourcePath{}, nil
+	}
+	id := ui.view.commits[i]
+	if id == "" {
+		// Rows such as the team headers stand for no commit, and must not
+		// match the frames without one.
+		return SourcePath{}, nil
+	}
+	if ui.view.frame != nil {
+		file := *ui.view.frame
+		if file.CommitID != id {
+			// The fallback is about the author of the blamed commit.
+			file.CommitID, file.Fallback = id, nil
+		}
+		return file, ui.dump.Commits.ByID[id]
+	}
+	for _, file := range ui.stackTrace {
+		if file.CommitID == id {
+			return file, ui.dump.Commits.ByID[id]
+		}
+	}
+	return SourcePath{Head: ui.dump.Revision, CommitID: id},
+		ui.dump.Commits.ByID[id]
 }
 
 func (ui *UI) refresh() {
@@ -165,25 +623,120 @@ func (ui *UI) RenderDump(dump Dump) {
 	stack, files := ui.format.Stacktrace(dump)
 	ui.stackTrace = files
 	first := 0
-	for first < len(ui.stackTrace) && ui.stackTrace[first].File == "" {
-		first++
+	if c := dump.PanicFrame(); c != nil {
+		for first < len(files) &&
+			(files[first].File != c.SourcePath || files[first].Line != c.Line) {
+			first++
+		}
+	} else {
+		for first < len(files) && files[first].File == "" {
+			first++
+		}
 	}
-	if first == len(ui.stackTrace) {
+	if first == len(files) {
 		first = 0
 	}
 	ui.widgets.stackTrace.SetItems(stack)
 	ui.widgets.stackTrace.Select(first)
+	if dump.WorkingTree {
+		ui.widgets.stackTrace.BorderLabel = "Stacktrace (working tree)"
+	}
+	if dump.MissingRevision != "" {
+		ui.widgets.stackTrace.BorderLabel = fmt.Sprintf(
+			"Stacktrace (at HEAD %.7s, %s is missing)", dump.Revision,
+			dump.MissingRevision)
+	}
+	if warning := dump.RevisionWarning(); warning != "" {
+		ui.showMessage(&warning)
+	} else if dump.source != nil && dump.source.Bisect != "" &&
+		dump.Bisection == nil {
+		status := "Bisecting with " + dump.source.Bisect + "..."
+		ui.showMessage(&status)
+	}
 	ui.updateCommit()
 	ui.refresh()
 }
 
+// evtBisection is the event bringing the outcome of a bisection to the
+// goroutine of the UI, the only one that may touch the dump once rendered.
+const evtBisection = "/usr/bisection"
+
+// RenderBisection shows the outcome of bisecting the dump rendered before.
+// It may be called from any goroutine.
+func (ui *UI) RenderBisection(b *Bisection) {
+	if b != nil {
+		termui.SendCustomEvt(evtBisection, b)
+	}
+}
+
+func (ui *UI) showBisection(b *Bisection) {
+	if ui.dump == nil {
+		return
+	}
+	if b.Culprit != nil {
+		b.Culprit = ui.dump.Commits.Index(*b.Culprit)
+	}
+	ui.dump.Bisection = b
+	status := "Bisection done!"
+	if b.Error != "" {
+		status = "Error: bisection failed: " + b.Error
+	}
+	ui.showMessage(&status)
+	if ui.view == nil || ui.view.name != "Bisect" {
+		ui.toggleView("Bisect", ui.bisectView)
+	}
+}
+
 func (ui *UI) updateCommit() {
-	id := ui.stackTrace[ui.widgets.stackTrace.CurrentItem].CommitID
-	if id == "" {
-		ui.widgets.commit.Text = ""
-	} else {
-		ui.widgets.commit.Text = ui.format.Commit(ui.dump.Commits.ByID[id])
+	if ui.dump == nil {
+		return
+	}
+	file, commit := ui.current()
+	ui.markCommits()
+	ui.widgets.commit.Text = ui.format.Commit(Frame{
+		SourcePath: file,
+		Commit:     commit,
+		Person:     ui.dump.Person(commit),
+	})
+}
+
+// selectedCommits returns the IDs of all the commits the selected item
+// stands for.
+func (ui *UI) selectedCommits() []string {
+	if ui.panelFocused {
+		i := ui.widgets.panel.CurrentItem
+		if i >= 0 && i < len(ui.view.related) && ui.view.related[i] != nil {
+			return ui.view.related[i]
+		}
+	}
+	if _, commit := ui.current(); commit != nil {
+		return []string{commit.ID}
+	}
+	return nil
+}
+
+// markCommits marks the stack frames and the panel items of the selected
+// commits.
+func (ui *UI) markCommits() {
+	ids := map[string]bool{}
+	for _, id := range ui.selectedCommits() {
+		ids[id] = true
+	}
+	var frames, items []int
+	for i, file := range ui.stackTrace {
+		if ids[file.CommitID] {
+			frames = append(frames, i)
+		}
+	}
+	if ui.view != nil {
+		for i, commit := range ui.view.commits {
+			if ids[commit] {
+				items = append(items, i)
+			}
+		}
 	}
+	ui.widgets.stackTrace.Mark(frames)
+	ui.widgets.panel.Mark(items)
 }
 
 func (ui *UI) showMessage(status *string) {
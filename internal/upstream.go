@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Upstream is a local clone of the repository of a dependency.
+type Upstream struct {
+	// Module is the path of the module at the root of the repository; it
+	// also matches the modules in its subdirectories.
+	Module     string `yaml:"module"`
+	Repository string `yaml:"repository"`
+}
+
+var (
+	rePseudoVersion = regexp.MustCompile(`-(?:0\.)?\d{14}-([0-9a-f]{12})$`)
+	reMajorVersion  = regexp.MustCompile(`(^|/)v\d+$`)
+	reRemote        = regexp.MustCompile(`^(?:[a-z+]+://)?(?:[^@/]+@)?([^/:]+)[:/](.+?)(?:\.git)?/?$`)
+)
+
+var errNoUpstream = errors.New("No clone of the dependency at its version")
+
+// upstreams finds the clones of the dependencies, as configured or else in
+// the VCS cache of the module cache.
+type upstreams struct {
+	configured []Upstream
+
+	once       sync.Once
+	discovered []Upstream
+}
+
+func newUpstreams(configured []Upstream) *upstreams {
+	return &upstreams{configured: configured}
+}
+
+// find returns the clone of the repository of the module, or nil if there
+// is none.
+func (u *upstreams) find(module string) *Upstream {
+	if up := longestModule(u.configured, module); up != nil {
+		return up
+	}
+	u.once.Do(u.discover)
+	return longestModule(u.discovered, module)
+}
+
+func longestModule(upstreams []Upstream, module string) *Upstream {
+	var found *Upstream
+	for i := range upstreams {
+		up := &upstreams[i]
+		if (module == up.Module || strings.HasPrefix(module, up.Module+"/")) &&
+			(found == nil || len(up.Module) > len(found.Module)) {
+			found = up
+		}
+	}
+	return found
+}
+
+// modCache returns the module cache directory.
+func modCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	gopath := filepath.SplitList(os.Getenv("GOPATH"))
+	if len(gopath) > 0 && gopath[0] != "" {
+		return filepath.Join(gopath[0], "pkg", "mod")
+	}
+	return filepath.Join(os.Getenv("HOME"), "go", "pkg", "mod")
+}
+
+// discover lists the bare repositories the go command cloned to resolve
+// modules, by the remote they were cloned from.
+func (u *upstreams) discover() {
+	vcs := filepath.Join(modCache(), "cache", "vcs")
+	entries, err := ioutil.ReadDir(vcs)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(vcs, e.Name())
+		out, err := git(dir, "config", "remote.origin.url")
+		if err != nil {
+			continue
+		}
+		m := reRemote.FindStringSubmatch(strings.TrimSpace(string(out)))
+		if m == nil {
+			continue
+		}
+		u.discovered = append(u.discovered,
+			Upstream{Module: m[1] + "/" + m[2], Repository: dir})
+	}
+}
+
+// Blame blames the frame of the dependency in the clone of its repository,
+// at the revision matching the version of the module, and records where.
+func (u *upstreams) Blame(dep *Dependency, line int) (Commit, error) {
+	up := u.find(dep.Module)
+	if up == nil {
+		return Commit{}, errNoUpstream
+	}
+	version := dep.Version
+	if version == "" {
+		version = dep.New
+	}
+	subdir := strings.TrimPrefix(strings.TrimPrefix(dep.Module, up.Module), "/")
+	revision := upstreamRevision(up.Repository, subdir, version)
+	if revision == "" {
+		return Commit{}, errNoUpstream
+	}
+
+	path := filepath.ToSlash(filepath.Join(subdir, dep.File))
+	if _, err := git(up.Repository, "cat-file", "-e", revision+":"+path); err != nil {
+		// The major version suffix is not always a directory.
+		path = filepath.ToSlash(filepath.Join(
+			reMajorVersion.ReplaceAllString(subdir, ""), dep.File))
+	}
+	cm, err := Blame(up.Repository, path, line, revision)
+	if err != nil {
+		return Commit{}, err
+	}
+	dep.Upstream, dep.Revision, dep.Path = up.Repository, revision, path
+	return cm, nil
+}
+
+// upstreamRevision returns the commit of the version of the module in the
+// repository: the one of its tag, the one named by its pseudo-version, or
+// the version itself for the commits locked by glide.
+func upstreamRevision(repo, subdir, version string) string {
+	version = strings.TrimSuffix(version, "+incompatible")
+	var candidates []string
+	if m := rePseudoVersion.FindStringSubmatch(version); m != nil {
+		candidates = append(candidates, m[1])
+	}
+	if prefix := reMajorVersion.ReplaceAllString(subdir, ""); prefix != "" {
+		candidates = append(candidates, prefix+"/"+version)
+	}
+	candidates = append(candidates, version)
+	for _, c := range candidates {
+		if c == "" {
+			continue
+		}
+		out, err := git(repo, "rev-parse", "--verify", "--quiet", c+"^{commit}")
+		if err == nil {
+			return strings.TrimSpace(string(out))
+		}
+	}
+	return ""
+}
@@ -21,6 +21,22 @@ var (
 	config  = flag.String("config",
 		os.Getenv("HOME")+"/.iblameyou.yaml",
 		"path to configuration file")
+	output = flag.String("output", "ui",
+		"how to present the results: ui, json or markdown")
+	repository = flag.String("repository", "",
+		"local clone or clone URL of the repository (default: the current one)")
+	fetch = flag.String("fetch", "",
+		"fetch the revision if missing: deepen or unshallow a shallow clone")
+	workingTree = flag.Bool("working-tree", false,
+		"blame the working tree, uncommitted changes included")
+	good = flag.String("good", "",
+		"revision known not to crash, to list the commits since then")
+	bisect = flag.String("bisect", "",
+		"shell command reproducing the crash, to bisect from the good revision")
+	target = flag.String("target", "",
+		"branch to look for fixes of the crash in (default: origin/main or HEAD)")
+	bad = flag.String("bad", "",
+		"revision known to crash when bisecting (default: the dump's revision)")
 )
 
 type Config struct {
@@ -53,6 +69,28 @@ func main() {
 		}
 	}
 
+	if *repository != "" {
+		cfg.Source.Repository = *repository
+	}
+	if *fetch != "" {
+		cfg.Source.Fetch = *fetch
+	}
+	if *workingTree {
+		cfg.Source.WorkingTree = true
+	}
+	if *good != "" {
+		cfg.Source.Good = *good
+	}
+	if *bisect != "" {
+		cfg.Source.Bisect = *bisect
+	}
+	if *bad != "" {
+		cfg.Source.Bad = *bad
+	}
+	if *target != "" {
+		cfg.Source.Target = *target
+	}
+
 	if cfg.Source.Repository == "" {
 		log.Fatal("Repository not provided and not in a Git repository.")
 	}
@@ -63,6 +101,31 @@ func main() {
 		log.Fatal(err)
 	}
 
+	switch *output {
+	case "ui":
+	case "json", "markdown":
+		dump, err := cfg.Source.ParseDump(bytes.NewReader(message))
+		if err != nil {
+			log.Fatalf("Failed to parse dump:\n%s", err)
+		}
+		if cfg.Source.Bisect != "" {
+			log.Println("Bisecting...")
+			dump.Bisection = cfg.Source.BisectDump(&dump)
+		}
+		report := dump.Report()
+		if *output == "json" {
+			err = report.WriteJSON(os.Stdout)
+		} else {
+			err = report.WriteMarkdown(os.Stdout)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	default:
+		log.Fatalf("Unknown output %q.", *output)
+	}
+
 	ui := internal.UI{}
 	err = ui.Init(&cfg.Format)
 	if err != nil {
@@ -76,6 +139,7 @@ func main() {
 			log.Fatalf("Failed to parse dump:\n%s", err)
 		}
 		ui.RenderDump(dump)
+		ui.RenderBisection(cfg.Source.BisectDump(&dump))
 	}()
 
 	ui.Loop()
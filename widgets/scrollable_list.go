@@ -12,9 +12,11 @@ type ScrollableList struct {
 	SourceItems    []string
 	CurrentItem    int
 	HighlightColor string
+	MarkColor      string
 
 	scroll           int
 	highlightedItems []string
+	marked           map[int]bool
 }
 
 func NewScrollableList() *ScrollableList {
@@ -22,6 +24,7 @@ func NewScrollableList() *ScrollableList {
 		List:           termui.NewList(),
 		CurrentItem:    -1,
 		HighlightColor: "blue",
+		MarkColor:      "cyan",
 	}
 }
 
@@ -30,6 +33,45 @@ func (sl *ScrollableList) SetItems(items []string) {
 	copy(sl.SourceItems, items)
 	sl.highlightedItems = make([]string, len(items))
 	copy(sl.highlightedItems, items)
+	sl.marked = nil
+	sl.CurrentItem = -1
+	sl.scroll = 0
+	sl.Items = sl.highlightedItems
+}
+
+// restyle renders the item according to whether it is selected or marked.
+func (sl *ScrollableList) restyle(item int) {
+	color := ""
+	switch {
+	case item == sl.CurrentItem:
+		color = sl.HighlightColor
+	case sl.marked[item]:
+		color = sl.MarkColor
+	default:
+		sl.highlightedItems[item] = sl.SourceItems[item]
+		return
+	}
+	sl.highlightedItems[item] =
+		fmt.Sprintf("[%-*s](bg-%s)",
+			sl.InnerWidth()-1, clearStyles(sl.SourceItems[item]), color)
+}
+
+// Mark highlights the given items, e.g. the ones related to the current
+// one, replacing the previously marked items.
+func (sl *ScrollableList) Mark(items []int) {
+	old := sl.marked
+	sl.marked = make(map[int]bool, len(items))
+	for _, i := range items {
+		if i >= 0 && i < len(sl.SourceItems) {
+			sl.marked[i] = true
+		}
+	}
+	for i := range old {
+		sl.restyle(i)
+	}
+	for i := range sl.marked {
+		sl.restyle(i)
+	}
 }
 
 func (sl *ScrollableList) Select(item int) {
@@ -39,13 +81,13 @@ func (sl *ScrollableList) Select(item int) {
 
 	count := len(sl.SourceItems)
 
-	if sl.CurrentItem >= 0 && sl.CurrentItem < len(sl.SourceItems) {
-		sl.highlightedItems[sl.CurrentItem] = sl.SourceItems[sl.CurrentItem]
+	previous := sl.CurrentItem
+	sl.CurrentItem = item
+	if previous >= 0 && previous < len(sl.SourceItems) {
+		sl.restyle(previous)
 	}
 	if item >= 0 && item < count {
-		sl.highlightedItems[item] =
-			fmt.Sprintf("[%-*s](bg-%s)",
-				sl.InnerWidth()-1, clearStyles(sl.SourceItems[item]), sl.HighlightColor)
+		sl.restyle(item)
 
 		// Scroll up
 		if item <= sl.scroll && item != 0 {
@@ -62,8 +104,6 @@ func (sl *ScrollableList) Select(item int) {
 		}
 		sl.Items = sl.highlightedItems[sl.scroll:lastItem]
 	}
-
-	sl.CurrentItem = item
 }
 
 func (sl *ScrollableList) SelectNext() {